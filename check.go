@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/config"
+	"github.com/krukkrz/pagination/pkg/consistency"
+	"github.com/krukkrz/pagination/pkg/storage"
+)
+
+// runCheck walks an endpoint of an in-process server over the configured
+// storage while a writer writes to it, and fails when any row was skipped or
+// repeated.
+func runCheck(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: pagination check books|cars [--limit N] [--inserts N] [--deletes N]")
+	}
+	resource, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	cfg := config.Register(fs)
+	limit := fs.Int("limit", 20, "number of items requested per page")
+	inserts := fs.Int("inserts", 1, "rows inserted while every page is fetched")
+	deletes := fs.Int("deletes", 1, "random rows deleted while every page is fetched")
+	seed := fs.Int64("seed", 1, "seed picking the deleted rows, reported with the writes")
+	fs.Parse(args)
+
+	checkConfig := consistency.Config{Inserts: *inserts, Deletes: *deletes, Seed: *seed}
+	switch resource {
+	case "books":
+		checkConfig.First, checkConfig.Strategy = fmt.Sprintf("/v2/books?limit=%d&offset=0", *limit), "offset"
+	case "cars":
+		checkConfig.First, checkConfig.Strategy = fmt.Sprintf("/v2/cars?cursor=1&limit=%d", *limit), "cursor"
+	default:
+		return fmt.Errorf("unknown resource: %s, expected one of: books, cars", resource)
+	}
+
+	store, err := storage.Open(cfg.Storage, cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	table, ok := store.Checked(resource)
+	if !ok {
+		return fmt.Errorf("%s storage can not be checked", cfg.Storage)
+	}
+
+	report, err := consistency.Check(context.Background(), api.NewServer(store.Books, store.Cars).Handler(), table, checkConfig)
+	if err != nil {
+		return err
+	}
+	fmt.Println(report)
+	if !report.OK() {
+		return fmt.Errorf("pagination of %s is inconsistent", resource)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/client"
+	"github.com/krukkrz/pagination/pkg/config"
+	"io"
+	"os"
+)
+
+func runFetch(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: pagination fetch books|cars [--all] [--format ndjson|json]")
+	}
+	resource, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("fetch", flag.ExitOnError)
+	cfg := config.Register(fs)
+	all := fs.Bool("all", false, "follow next links until the last page")
+	limit := fs.Int("limit", 100, "number of items requested per page")
+	format := fs.String("format", "ndjson", "output format: ndjson prints one item per line, json prints an array")
+	prefetch := fs.Bool("prefetch", false, "fetch the next page while printing the current one")
+	fs.Parse(args)
+
+	if *format != "ndjson" && *format != "json" {
+		return fmt.Errorf("unknown format: %s, expected one of: ndjson, json", *format)
+	}
+
+	var opts []client.Option
+	if cfg.APIKey != "" {
+		opts = append(opts, client.WithAPIKey(cfg.APIKey))
+	}
+	c, err := client.New(cfg.URL, opts...)
+	if err != nil {
+		return err
+	}
+	var pagerOpts []client.PagerOption
+	if *prefetch {
+		pagerOpts = append(pagerOpts, client.WithPrefetch())
+	}
+
+	out := newPrinter(os.Stdout, *format)
+	ctx := context.Background()
+	switch resource {
+	case "books":
+		err = printPages(ctx, c.Books(*limit, pagerOpts...), *all, out)
+	case "cars":
+		err = printPages(ctx, c.Cars(*limit, pagerOpts...), *all, out)
+	default:
+		return fmt.Errorf("unknown resource: %s, expected one of: books, cars", resource)
+	}
+	if err != nil {
+		return err
+	}
+	return out.close()
+}
+
+func printPages[T any](ctx context.Context, pager *client.Pager[T], all bool, out *printer) error {
+	if all {
+		return pager.All(ctx, func(item T) error {
+			return out.print(item)
+		})
+	}
+	items, err := pager.Next(ctx)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := out.print(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printer writes items as they come, either one per line or as elements of a
+// single JSON array.
+type printer struct {
+	w       io.Writer
+	encoder *json.Encoder
+	array   bool
+	count   int
+}
+
+func newPrinter(w io.Writer, format string) *printer {
+	return &printer{w: w, encoder: json.NewEncoder(w), array: format == "json"}
+}
+
+func (p *printer) print(item any) error {
+	if p.array {
+		separator := ","
+		if p.count == 0 {
+			separator = "["
+		}
+		if _, err := io.WriteString(p.w, separator); err != nil {
+			return err
+		}
+	}
+	p.count++
+	return p.encoder.Encode(item)
+}
+
+func (p *printer) close() error {
+	if !p.array {
+		return nil
+	}
+	if p.count == 0 {
+		_, err := io.WriteString(p.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(p.w, "]\n")
+	return err
+}
@@ -4,4 +4,4 @@ go 1.20
 
 require github.com/proullon/ramsql v0.0.0-20230224205054-8ff679dbf7aa
 
-require github.com/lib/pq v1.10.7 // indirect
+require github.com/lib/pq v1.10.7
@@ -1,20 +1,47 @@
 package main
 
 import (
-	"github.com/krukkrz/pagination/pkg/api"
-	"github.com/krukkrz/pagination/pkg/books"
-	"github.com/krukkrz/pagination/pkg/cars"
-	"github.com/krukkrz/pagination/pkg/database"
+	"fmt"
 	"log"
+	"os"
+	"strings"
 )
 
+var commands = map[string]func(args []string) error{
+	"serve":   runServe,
+	"migrate": runMigrate,
+	"seed":    runSeed,
+	"fetch":   runFetch,
+	"check":   runCheck,
+}
+
+const usage = `usage: pagination <command> [flags]
+
+commands:
+  serve                              start the API (default)
+  migrate up|down|status|sql         manage the database schema
+  seed --books N --cars N            insert generated books and cars
+  fetch books|cars [--all] [--format ndjson|json]
+                                     call the API and print the results
+  check books|cars [--inserts N] [--deletes N]
+                                     walk an endpoint while writing to it and
+                                     report skipped or repeated rows
+
+Run pagination <command> --help for the flags of a command.
+`
+
 func main() {
-	log.Println("Starting application...")
-	db := database.Connect()
-	bookRepository := books.NewRepository(db)
-	carRepository := cars.NewRepository(db)
-	server := api.NewServer(bookRepository, carRepository)
-	server.Start(":8000")
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
 
-	//todo dockerize everything
+	run, ok := commands[command]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err := run(args); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/config"
+	"github.com/krukkrz/pagination/pkg/database"
+	"github.com/krukkrz/pagination/pkg/migrate"
+	"github.com/krukkrz/pagination/pkg/storage"
+	"log"
+	"os"
+	"time"
+)
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: pagination migrate up [--to version]|down|status|sql")
+	}
+	command, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	cfg := config.Register(fs)
+	to := fs.Int("to", 0, "version to migrate up to, 0 applies every pending migration")
+	fs.Parse(args)
+
+	if command == "sql" {
+		// the script is written without a database, db/docker-compose.yml
+		// initializes postgres with it
+		m, err := migrate.New(nil)
+		if err != nil {
+			return err
+		}
+		return m.Script(os.Stdout)
+	}
+
+	db, err := connect(cfg, "migrate")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	m, err := migrate.New(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch command {
+	case "up":
+		applied, err := m.UpTo(ctx, *to)
+		for _, migration := range applied {
+			log.Printf("applied migration %d_%s", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			log.Printf("schema is up to date")
+		}
+		return err
+	case "down":
+		migration, reverted, err := m.Down(ctx)
+		if reverted {
+			log.Printf("reverted migration %d_%s", migration.Version, migration.Name)
+		} else if err == nil {
+			log.Printf("no migration to revert")
+		}
+		return err
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range status {
+			applied := "pending"
+			if s.Applied {
+				applied = "applied at " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command: %s, expected one of: up, down, status, sql", command)
+	}
+}
+
+// connect opens the postgres database of the config. The other storages live
+// in the serving process, so there is nothing to migrate or seed.
+func connect(cfg *config.Config, command string) (*sql.DB, error) {
+	if cfg.Storage != storage.Postgres {
+		return nil, fmt.Errorf("%s needs the %s storage, got: %s", command, storage.Postgres, cfg.Storage)
+	}
+	return database.Connect(cfg.Database)
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+)
+
+// AdminHandler serves the variables published with expvar on /debug/vars. It
+// skips authentication, rate limiting and CORS, so it belongs on a listener of
+// its own which is not reachable publicly.
+func AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/vars", vars)
+	return mux
+}
+
+// vars writes the variables like expvar.Handler, but without cmdline: flags
+// such as --db-password are part of it.
+func vars(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprint(rw, "{\n")
+	first := true
+	expvar.Do(func(kv expvar.KeyValue) {
+		if kv.Key == "cmdline" {
+			return
+		}
+		if !first {
+			fmt.Fprint(rw, ",\n")
+		}
+		first = false
+		fmt.Fprintf(rw, "%q: %s", kv.Key, kv.Value)
+	})
+	fmt.Fprint(rw, "\n}\n")
+}
@@ -0,0 +1,40 @@
+package api_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/api/internal"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminHandler(t *testing.T) {
+	if expvar.Get("admin_test") == nil {
+		expvar.NewInt("admin_test").Set(42)
+	}
+
+	rr := httptest.NewRecorder()
+	api.AdminHandler().ServeHTTP(rr, httptest.NewRequest("GET", "/debug/vars", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("admin handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var vars map[string]json.RawMessage
+	if err := json.NewDecoder(rr.Body).Decode(&vars); err != nil {
+		t.Fatalf("unexpected error while parsing response body: %v", err)
+	}
+	if string(vars["admin_test"]) != "42" {
+		t.Errorf("expecting published variables, got: %s", vars["admin_test"])
+	}
+	if _, ok := vars["cmdline"]; ok {
+		t.Errorf("expecting the command line to be left out")
+	}
+
+	rr = httptest.NewRecorder()
+	srv := api.NewServer(internal.BookRepositoryMockReturnBooks(10, 0, t), internal.CarRepositoryMockReturnCars(10, 0, t))
+	srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/debug/vars", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expecting /debug/vars to be off the public routes, got: %v", rr.Code)
+	}
+}
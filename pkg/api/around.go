@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/contract"
+	"github.com/krukkrz/pagination/pkg/problem"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// AroundCarRepository reads the cars before a cursor, which together with the
+// cars from the cursor on make the page around a car.
+type AroundCarRepository interface {
+	FetchBefore(ctx context.Context, cursor, limit int) ([]carsModels.Car, error)
+}
+
+// CountCarRepository counts the cars before a cursor, which tells the position
+// of a car in the collection.
+type CountCarRepository interface {
+	CountBefore(ctx context.Context, cursor int) (int, error)
+}
+
+// WithAround serves the page centred on a car with ?around=<id>.
+func WithAround(cars AroundCarRepository) Option {
+	return func(s *Server) {
+		s.aroundCars = cars
+	}
+}
+
+// WithCounts adds the position of the car to pages around it. Counting reads
+// every car before it, so it is left out unless enabled.
+func WithCounts(cars CountCarRepository) Option {
+	return func(s *Server) {
+		s.countCars = cars
+	}
+}
+
+// aroundPage is a page centred on a car. Prev and Next are the cursors of the
+// pages before and after it, 0 when there is none.
+type aroundPage struct {
+	cars     []carsModels.Car
+	prev     int
+	next     int
+	position *int
+}
+
+func (s Server) fetchCarsAround(rw http.ResponseWriter, r *http.Request, version Version, format Format) {
+	if s.aroundCars == nil {
+		problem.Write(rw, http.StatusBadRequest, "pages around a car are not enabled, use cursor")
+		return
+	}
+	around, err := strconv.Atoi(r.URL.Query().Get("around"))
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	limit, ok := s.parseLimit(rw, r)
+	if !ok {
+		return
+	}
+
+	log.Printf("received a request around: %d with limit: %d", around, limit)
+
+	page, err := s.carsAround(r.Context(), around, limit)
+	if err != nil {
+		log.Printf("error while fetching cars: %v", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if page == nil {
+		problem.Write(rw, http.StatusNotFound, fmt.Sprintf("car %d not found", around))
+		return
+	}
+
+	if notModified(rw, r, pageETag(version, format, page.cars), s.cachePolicy.Page) {
+		return
+	}
+
+	link := func(cursor int) string {
+		return fmt.Sprintf("%s?cursor=%d&limit=%d", r.URL.Path, cursor, limit)
+	}
+	switch version {
+	case V2:
+		links := contract.PageLinks{
+			Self:  fmt.Sprintf("%s?around=%d&limit=%d", r.URL.Path, around, limit),
+			First: link(1),
+		}
+		if page.prev > 0 {
+			prev := link(page.prev)
+			links.Prev = &prev
+		}
+		if page.next > 0 {
+			next := link(page.next)
+			links.Next = &next
+		}
+		cursor := page.cars[0].Id
+		response := contract.PageResponse[carsModels.Car]{
+			Data:  page.cars,
+			Links: links,
+			Meta:  contract.PageMeta{Limit: limit, Cursor: &cursor, Around: &around, Position: page.position, Count: len(page.cars)},
+		}
+		s.writePage(rw, r, format, response, response.Data, pageRelations(links))
+	default:
+		links := LinksResponse{First: link(1)}
+		if page.prev > 0 {
+			links.Prev = link(page.prev)
+		}
+		if page.next > 0 {
+			links.Next = link(page.next)
+		}
+		data := carsV1(page.cars)
+		s.writePage(rw, r, format, PaginatedResponse[CarV1]{Data: data, Links: links}, data, links.relations())
+	}
+}
+
+// carsAround reads half of the page before the car and the rest from the car
+// on, with two keyset queries. Near the start the page is filled with cars
+// after the car and near the end with cars before it. Both queries read past
+// the page, so the cursors of the neighbouring pages are exact. It returns nil
+// when the car does not exist.
+func (s Server) carsAround(ctx context.Context, around, limit int) (*aroundPage, error) {
+	// up to limit cars of the page and limit more to find where the previous
+	// page starts
+	before, err := s.aroundCars.FetchBefore(ctx, around, 2*limit)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.carRepository.FetchAll(ctx, around, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(after) == 0 || after[0].Id != around {
+		return nil, nil
+	}
+
+	taken := limit / 2
+	if len(after) < limit-taken {
+		taken = limit - len(after)
+	}
+	if len(before) < taken {
+		taken = len(before)
+	}
+
+	page := &aroundPage{cars: make([]carsModels.Car, 0, limit)}
+	for i := taken - 1; i >= 0; i-- {
+		page.cars = append(page.cars, before[i])
+	}
+	if len(after) > limit-taken {
+		page.next = after[limit-taken].Id
+		after = after[:limit-taken]
+	}
+	page.cars = append(page.cars, after...)
+	if len(before) > taken {
+		// the previous page ends right before the page, so it starts limit cars
+		// earlier, or at the first car
+		start := taken + limit
+		if start > len(before) {
+			start = len(before)
+		}
+		page.prev = before[start-1].Id
+	}
+
+	if s.countCars != nil {
+		count, err := s.countCars.CountBefore(ctx, around)
+		if err != nil {
+			return nil, err
+		}
+		position := count + 1
+		page.position = &position
+	}
+	return page, nil
+}
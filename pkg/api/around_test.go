@@ -0,0 +1,130 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/api/internal"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/contract"
+	"github.com/krukkrz/pagination/pkg/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAround(t *testing.T) {
+	cars := memory.NewCarRepository()
+	// ids with gaps, every second car
+	for i := 1; i <= 50; i++ {
+		cars.Insert(carsModels.Car{Id: i * 2, Brand: fmt.Sprintf("brand %d", i), Model: fmt.Sprintf("model %d", i), CreatedAt: time.Now()})
+	}
+	srv := api.NewServer(internal.BookRepositoryMockReturnBooks(10, 0, t), cars, api.WithAround(cars), api.WithCounts(cars))
+
+	testCases := []struct {
+		name             string
+		url              string
+		expectedFirst    int
+		expectedLast     int
+		expectedPrev     string
+		expectedNext     string
+		expectedPosition int
+	}{
+		{
+			name:             "page is centred on the car",
+			url:              "/v2/cars?around=50&limit=10",
+			expectedFirst:    40,
+			expectedLast:     58,
+			expectedPrev:     "/v2/cars?cursor=20&limit=10",
+			expectedNext:     "/v2/cars?cursor=60&limit=10",
+			expectedPosition: 25,
+		},
+		{
+			name:             "page near the start is filled with later cars",
+			url:              "/v2/cars?around=4&limit=10",
+			expectedFirst:    2,
+			expectedLast:     20,
+			expectedNext:     "/v2/cars?cursor=22&limit=10",
+			expectedPosition: 2,
+		},
+		{
+			name:             "previous page close to the start begins at the first car",
+			url:              "/v2/cars?around=20&limit=10",
+			expectedFirst:    10,
+			expectedLast:     28,
+			expectedPrev:     "/v2/cars?cursor=2&limit=10",
+			expectedNext:     "/v2/cars?cursor=30&limit=10",
+			expectedPosition: 10,
+		},
+		{
+			name:             "page near the end is filled with earlier cars",
+			url:              "/v2/cars?around=98&limit=10",
+			expectedFirst:    82,
+			expectedLast:     100,
+			expectedPrev:     "/v2/cars?cursor=62&limit=10",
+			expectedPosition: 49,
+		},
+		{
+			name:             "page around the last car ends with it",
+			url:              "/v2/cars?around=100&limit=10",
+			expectedFirst:    82,
+			expectedLast:     100,
+			expectedPrev:     "/v2/cars?cursor=62&limit=10",
+			expectedPosition: 50,
+		},
+		{
+			name:             "page of a single car",
+			url:              "/v2/cars?around=100&limit=1",
+			expectedFirst:    100,
+			expectedLast:     100,
+			expectedPrev:     "/v2/cars?cursor=98&limit=1",
+			expectedPosition: 50,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", tc.url, nil))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("api returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+			var page contract.PageResponse[carsModels.Car]
+			if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+				t.Fatalf("unexpected error while parsing response body: %v", err)
+			}
+
+			if first, last := page.Data[0].Id, page.Data[len(page.Data)-1].Id; first != tc.expectedFirst || last != tc.expectedLast {
+				t.Errorf("unexpected page, got cars %d to %d, expected: %d to %d", first, last, tc.expectedFirst, tc.expectedLast)
+			}
+			assertLink(t, "self", &page.Links.Self, tc.url)
+			assertLink(t, "prev", page.Links.Prev, tc.expectedPrev)
+			assertLink(t, "next", page.Links.Next, tc.expectedNext)
+			if page.Meta.Position == nil || *page.Meta.Position != tc.expectedPosition {
+				t.Errorf("unexpected position, got: %v, expected: %d", page.Meta.Position, tc.expectedPosition)
+			}
+		})
+	}
+
+	t.Run("missing cars are not found", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/v2/cars?around=51&limit=10", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("api returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("position is left out without counts", func(t *testing.T) {
+		srv := api.NewServer(internal.BookRepositoryMockReturnBooks(10, 0, t), cars, api.WithAround(cars))
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/v2/cars?around=50&limit=10", nil))
+		var page contract.PageResponse[carsModels.Car]
+		if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+			t.Fatalf("unexpected error while parsing response body: %v", err)
+		}
+		if page.Meta.Position != nil {
+			t.Errorf("expecting no position, got: %d", *page.Meta.Position)
+		}
+	})
+}
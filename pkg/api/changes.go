@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/problem"
+	"github.com/krukkrz/pagination/pkg/repository"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultChangesLimit = 100
+	maxChangesLimit     = 1000
+)
+
+type BookChangeFeed interface {
+	Changes(ctx context.Context, after repository.ChangePosition, limit int) ([]repository.Change[booksModels.Book], error)
+}
+
+type CarChangeFeed interface {
+	Changes(ctx context.Context, after repository.ChangePosition, limit int) ([]repository.Change[carsModels.Car], error)
+}
+
+// ChangesResponse is a page of a change feed. Next is the token of the
+// following page and is returned even when the page is empty, so a client
+// keeps polling with it.
+type ChangesResponse[T any] struct {
+	Data    []repository.Change[T] `json:"data"`
+	Next    string                 `json:"next"`
+	HasMore bool                   `json:"has_more"`
+}
+
+// WithChanges serves the inserts, updates and deletes of books and cars in
+// commit order on /books/changes and /cars/changes.
+func WithChanges(books BookChangeFeed, cars CarChangeFeed) Option {
+	return func(s *Server) {
+		s.bookChanges = books
+		s.carChanges = cars
+	}
+}
+
+// BookChanges serves the changes of books on GET /books/changes?since=<token>.
+func (s Server) BookChanges(rw http.ResponseWriter, r *http.Request) {
+	changes(s, rw, r, s.bookChanges.Changes)
+}
+
+// CarChanges serves the changes of cars on GET /cars/changes?since=<token>.
+func (s Server) CarChanges(rw http.ResponseWriter, r *http.Request) {
+	changes(s, rw, r, s.carChanges.Changes)
+}
+
+func changes[T any](s Server, rw http.ResponseWriter, r *http.Request, fetch func(context.Context, repository.ChangePosition, int) ([]repository.Change[T], error)) {
+	log.Printf("received a changes request: %s", r.RequestURI)
+	if !validateGetRequest(rw, r) {
+		return
+	}
+	query := r.URL.Query()
+
+	var since repository.ChangePosition
+	if token := query.Get("since"); token != "" {
+		var err error
+		if since, err = decodeChangePosition(token); err != nil {
+			problem.Write(rw, http.StatusBadRequest, "malformed since token")
+			return
+		}
+	}
+	limit := defaultChangesLimit
+	if param := query.Get("limit"); param != "" {
+		var err error
+		if limit, err = strconv.Atoi(param); err != nil || limit < 1 || limit > maxChangesLimit {
+			problem.Write(rw, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxChangesLimit))
+			return
+		}
+	}
+
+	// one change more tells whether there are more to fetch right away
+	changes, err := fetch(r.Context(), since, limit+1)
+	if err != nil {
+		log.Printf("error while fetching changes: %v", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	response := ChangesResponse[T]{HasMore: len(changes) > limit}
+	if response.HasMore {
+		changes = changes[:limit]
+	}
+	next := since
+	if len(changes) > 0 {
+		next = changes[len(changes)-1].Position
+	}
+	response.Data = nonNil(changes)
+	response.Next = encodeChangePosition(next)
+	// the same token returns new changes as they are committed
+	rw.Header().Set("Cache-Control", "no-store")
+	s.encodeJsonResponse(rw, r, response)
+}
+
+func encodeChangePosition(p repository.ChangePosition) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d,%d", p.Txid, p.Id)))
+}
+
+func decodeChangePosition(token string) (repository.ChangePosition, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return repository.ChangePosition{}, err
+	}
+	txid, id, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return repository.ChangePosition{}, fmt.Errorf("missing change id")
+	}
+	var p repository.ChangePosition
+	if p.Txid, err = strconv.ParseInt(txid, 10, 64); err != nil {
+		return repository.ChangePosition{}, err
+	}
+	if p.Id, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return repository.ChangePosition{}, err
+	}
+	return p, nil
+}
@@ -0,0 +1,132 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/api/internal"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	"github.com/krukkrz/pagination/pkg/repository"
+	"github.com/krukkrz/pagination/pkg/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChanges(t *testing.T) {
+	createdAt := time.Date(2023, 3, 23, 19, 0, 0, 0, time.UTC)
+	book := func(id int, title string) booksModels.Book {
+		return booksModels.Book{Id: id, Title: title, Author: fmt.Sprintf("author %d", id), CreatedAt: createdAt}
+	}
+	books := memory.NewBookRepository()
+	cars := memory.NewCarRepository()
+	srv := api.NewServer(books, internal.CarRepositoryMockReturnCars(10, 0, t), api.WithChanges(books, cars))
+
+	type change struct {
+		operation string
+		id        int
+		title     string
+	}
+	// follow reads every change after the token in pages of two and returns
+	// them with the token to continue with
+	follow := func(t *testing.T, since string) ([]change, string) {
+		t.Helper()
+		var all []change
+		for {
+			rr := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/books/changes?limit=2&since="+since, nil))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("api returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+			if cacheControl := rr.Header().Get("Cache-Control"); cacheControl != "no-store" {
+				t.Errorf("unexpected Cache-Control header, got: %q", cacheControl)
+			}
+			var page api.ChangesResponse[booksModels.Book]
+			if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+				t.Fatalf("unexpected error while parsing response body: %v", err)
+			}
+			if page.Next == "" {
+				t.Fatalf("expecting every page to carry a token")
+			}
+			for _, c := range page.Data {
+				title := ""
+				if c.Item != nil {
+					title = c.Item.Title
+				}
+				all = append(all, change{operation: c.Operation, id: c.Key, title: title})
+			}
+			since = page.Next
+			if !page.HasMore {
+				return all, since
+			}
+		}
+	}
+
+	books.Insert(book(1, "first"), book(2, "second"), book(3, "third"))
+	changes, token := follow(t, "")
+	expected := []change{
+		{repository.Insert, 1, "first"},
+		{repository.Insert, 2, "second"},
+		{repository.Insert, 3, "third"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("unexpected changes returned, got: %v, expected: %v", changes, expected)
+	}
+
+	changes, token = follow(t, token)
+	if len(changes) != 0 {
+		t.Errorf("expecting no changes without writes, got: %v", changes)
+	}
+
+	books.Update(book(2, "second edition"))
+	books.Delete(3)
+	books.Insert(book(4, "fourth"))
+	changes, _ = follow(t, token)
+	expected = []change{
+		{repository.Update, 2, "second edition"},
+		{repository.Delete, 3, ""},
+		{repository.Insert, 4, "fourth"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("unexpected changes returned, got: %v, expected: %v", changes, expected)
+	}
+}
+
+func TestChangesErrors(t *testing.T) {
+	books := memory.NewBookRepository()
+	srv := api.NewServer(books, internal.CarRepositoryMockReturnCars(10, 0, t), api.WithChanges(books, memory.NewCarRepository()))
+
+	testCases := []struct {
+		name           string
+		url            string
+		expectedStatus int
+	}{
+		{
+			name:           "rejects a malformed token",
+			url:            "/cars/changes?since=not-a-token",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "rejects a limit above the maximum",
+			url:            "/books/changes?limit=1001",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "starts from the beginning without a token",
+			url:            "/cars/changes",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", tc.url, nil))
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("api returned wrong status code: got %v want %v", rr.Code, tc.expectedStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/checkpoint"
+	"github.com/krukkrz/pagination/pkg/contract"
+	"github.com/krukkrz/pagination/pkg/problem"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SeekCarRepository reads a page of cars found through a checkpoint.
+type SeekCarRepository interface {
+	FetchSeek(ctx context.Context, from, skip, limit int) ([]carsModels.Car, error)
+}
+
+// WithCheckpoints lets clients jump to a page of cars with ?page=N, which is
+// sought from the nearest checkpoint of the index instead of walking every
+// page before it.
+func WithCheckpoints(index *checkpoint.Index, cars SeekCarRepository) Option {
+	return func(s *Server) {
+		s.checkpoints = index
+		s.seekCars = cars
+	}
+}
+
+// seekPage reads the cars of a numbered page, problems are written to the
+// response. The age of the checkpoint is sent in the Checkpoint-Age header
+// only, the body stays the same while the checkpoint ages, like its ETag.
+func (s Server) seekPage(rw http.ResponseWriter, r *http.Request, number, limit int) ([]carsModels.Car, bool) {
+	if s.checkpoints == nil {
+		problem.Write(rw, http.StatusBadRequest, "numbered pages are not enabled, use cursor")
+		return nil, false
+	}
+	if number-1 > math.MaxInt/limit {
+		problem.Write(rw, http.StatusBadRequest, "page is out of range")
+		return nil, false
+	}
+	seek, ok := s.checkpoints.Seek((number - 1) * limit)
+	if !ok {
+		rw.Header().Set("Retry-After", "1")
+		problem.Write(rw, http.StatusServiceUnavailable, "checkpoints are being built")
+		return nil, false
+	}
+
+	cars, err := s.seekCars.FetchSeek(r.Context(), seek.From, seek.Skip, limit)
+	if err != nil {
+		log.Printf("error while fetching cars: %v", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+	// pages are approximate, rows inserted or deleted since the checkpoint was
+	// built shift them
+	age := int(time.Since(seek.BuiltAt).Seconds())
+	rw.Header().Set("Checkpoint-Age", strconv.Itoa(age))
+	return cars, true
+}
+
+// numberedPage points the links of a page found through a checkpoint at the
+// numbered pages around it.
+func numberedPage(r *http.Request, page *contract.PageResponse[carsModels.Car], number, limit int) {
+	link := func(number int) string {
+		return fmt.Sprintf("%s?page=%d&limit=%d", r.URL.Path, number, limit)
+	}
+	page.Links.Self = link(number)
+	page.Links.Prev = nil
+	if number > 1 {
+		prev := link(number - 1)
+		page.Links.Prev = &prev
+	}
+	if page.Links.Next != nil {
+		next := link(number + 1)
+		page.Links.Next = &next
+	}
+	page.Meta.Page = &number
+}
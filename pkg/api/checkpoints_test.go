@@ -0,0 +1,96 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/api/internal"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/checkpoint"
+	"github.com/krukkrz/pagination/pkg/contract"
+	"github.com/krukkrz/pagination/pkg/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNumberedPages(t *testing.T) {
+	cars := memory.NewCarRepository()
+	// ids with gaps, so the page cannot be computed from the ids
+	for i := 1; i <= 100; i++ {
+		cars.Insert(carsModels.Car{Id: i * 3, Brand: fmt.Sprintf("brand %d", i), Model: fmt.Sprintf("model %d", i), CreatedAt: time.Now()})
+	}
+	index := checkpoint.New(cars.Checkpoints, 7)
+	if err := index.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	srv := api.NewServer(
+		internal.BookRepositoryMockReturnBooks(10, 0, t),
+		cars,
+		api.WithCheckpoints(index, cars),
+	)
+
+	rr := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/v2/cars?page=4&limit=10", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("api returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var page contract.PageResponse[carsModels.Car]
+	if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+		t.Fatalf("unexpected error while parsing response body: %v", err)
+	}
+
+	if first, last := page.Data[0].Id, page.Data[len(page.Data)-1].Id; len(page.Data) != 10 || first != 93 || last != 120 {
+		t.Errorf("expecting cars 31 to 40 of the collection, got %d cars from id %d to %d", len(page.Data), first, last)
+	}
+	if rr.Header().Get("Checkpoint-Age") != "0" || page.Meta.Page == nil || *page.Meta.Page != 4 {
+		t.Errorf("unexpected checkpoint age header %q or meta: %+v", rr.Header().Get("Checkpoint-Age"), page.Meta)
+	}
+	assertLink(t, "self", &page.Links.Self, "/v2/cars?page=4&limit=10")
+	assertLink(t, "prev", page.Links.Prev, "/v2/cars?page=3&limit=10")
+	assertLink(t, "next", page.Links.Next, "/v2/cars?page=5&limit=10")
+
+	t.Run("not modified pages still carry the checkpoint age", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/v2/cars?page=4&limit=10", nil)
+		req.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+		cached := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(cached, req)
+		if cached.Code != http.StatusNotModified || cached.Header().Get("Checkpoint-Age") == "" {
+			t.Errorf("expecting 304 with a Checkpoint-Age header, got: %v and %q", cached.Code, cached.Header().Get("Checkpoint-Age"))
+		}
+	})
+
+	t.Run("last numbered page has no next page", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/v2/cars?page=4&limit=30", nil))
+		var page contract.PageResponse[carsModels.Car]
+		if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+			t.Fatalf("unexpected error while parsing response body: %v", err)
+		}
+		if len(page.Data) != 10 {
+			t.Errorf("expecting the last 10 cars, got: %d", len(page.Data))
+		}
+		assertLink(t, "prev", page.Links.Prev, "/v2/cars?page=3&limit=30")
+		assertLink(t, "next", page.Links.Next, "")
+	})
+
+	t.Run("numbered pages need checkpoints", func(t *testing.T) {
+		srv := api.NewServer(internal.BookRepositoryMockReturnBooks(10, 0, t), cars)
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/v2/cars?page=4&limit=10", nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("api returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+	t.Run("rejects limits which do not make a page", func(t *testing.T) {
+		for _, url := range []string{"/v2/cars?page=2&limit=-1000", "/v2/cars?page=2&limit=0", "/v2/cars?page=9223372036854775807&limit=10"} {
+			rr := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("api returned wrong status code for %s: got %v want %v", url, rr.Code, http.StatusBadRequest)
+			}
+		}
+	})
+}
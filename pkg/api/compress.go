@@ -0,0 +1,138 @@
+package api
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Compression configures gzip compression of responses. Responses shorter
+// than MinSize bytes are sent as they are.
+type Compression struct {
+	Level   int
+	MinSize int
+}
+
+var defaultCompression = Compression{
+	Level:   gzip.DefaultCompression,
+	MinSize: 1024,
+}
+
+// Validate returns an error when the level is neither
+// gzip.DefaultCompression nor between gzip.NoCompression and
+// gzip.BestCompression.
+func (c Compression) Validate() error {
+	if c.Level != gzip.DefaultCompression && (c.Level < gzip.NoCompression || c.Level > gzip.BestCompression) {
+		return fmt.Errorf("invalid gzip level %d, expecting -1 or 0 to 9", c.Level)
+	}
+	return nil
+}
+
+func WithCompression(compression Compression) Option {
+	return func(s *Server) {
+		s.compression = compression
+	}
+}
+
+// compress returns a writer for the response body which gzips it when the
+// client accepts gzip and the body reaches MinSize. Only the first MinSize
+// bytes are held back to decide, everything after is streamed through.
+func (s Server) compress(rw http.ResponseWriter, r *http.Request) io.WriteCloser {
+	if s.compression.Level == gzip.NoCompression {
+		return nopCloser{rw}
+	}
+	rw.Header().Add("Vary", "Accept-Encoding")
+	if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		return nopCloser{rw}
+	}
+	return &gzipWriter{rw: rw, compression: s.compression}
+}
+
+type gzipWriter struct {
+	rw          http.ResponseWriter
+	compression Compression
+	pending     []byte
+	gz          *gzip.Writer
+}
+
+func (w *gzipWriter) Write(p []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+
+	w.pending = append(w.pending, p...)
+	if len(w.pending) < w.compression.MinSize {
+		return len(p), nil
+	}
+
+	w.rw.Header().Set("Content-Encoding", "gzip")
+	w.rw.Header().Del("Content-Length")
+	if etag := w.rw.Header().Get("ETag"); etag != "" {
+		w.rw.Header().Set("ETag", gzipETag(etag))
+	}
+	gz, err := gzip.NewWriterLevel(w.rw, w.compression.Level)
+	if err != nil {
+		return 0, err
+	}
+	w.gz = gz
+	if _, err := w.gz.Write(w.pending); err != nil {
+		return 0, err
+	}
+	w.pending = nil
+	return len(p), nil
+}
+
+// Flush pushes what was compressed so far to the client.
+func (w *gzipWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if flusher, ok := w.rw.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *gzipWriter) Close() error {
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	_, err := w.rw.Write(w.pending)
+	return err
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func (w nopCloser) Flush() {
+	if flusher, ok := w.Writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func acceptsGzip(acceptEncoding string) bool {
+	accepted := false
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "gzip" && name != "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, _ = strconv.ParseFloat(value, 64)
+		}
+		if name == "gzip" {
+			return q > 0
+		}
+		accepted = q > 0
+	}
+	return accepted
+}
@@ -0,0 +1,106 @@
+package api_test
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/api/internal"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	testCases := []struct {
+		name               string
+		compression        api.Compression
+		acceptEncoding     string
+		expectedCompressed bool
+	}{
+		{
+			name:               "compresses responses above the threshold",
+			compression:        api.Compression{Level: gzip.BestSpeed, MinSize: 100},
+			acceptEncoding:     "br, gzip",
+			expectedCompressed: true,
+		},
+		{
+			name:               "accepts gzip through a wildcard",
+			compression:        api.Compression{Level: gzip.BestSpeed, MinSize: 100},
+			acceptEncoding:     "*",
+			expectedCompressed: true,
+		},
+		{
+			name:           "keeps responses below the threshold as they are",
+			compression:    api.Compression{Level: gzip.BestSpeed, MinSize: 1 << 20},
+			acceptEncoding: "gzip",
+		},
+		{
+			name:           "respects gzip refused by the client",
+			compression:    api.Compression{Level: gzip.BestSpeed, MinSize: 100},
+			acceptEncoding: "gzip;q=0, *",
+		},
+		{
+			name:           "does not compress for clients without Accept-Encoding",
+			compression:    api.Compression{Level: gzip.BestSpeed, MinSize: 100},
+			acceptEncoding: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := api.NewServer(
+				internal.BookRepositoryMockReturnBooks(10, 0, t),
+				internal.CarRepositoryMockReturnCars(10, 1, t),
+				api.WithCompression(tc.compression),
+			)
+
+			req := httptest.NewRequest("GET", "/v1/books?limit=10&offset=0", nil)
+			if tc.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+			rr := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rr, req)
+
+			if !strings.Contains(strings.Join(rr.Header().Values("Vary"), ","), "Accept-Encoding") {
+				t.Errorf("expecting Vary to contain Accept-Encoding, got: %v", rr.Header().Values("Vary"))
+			}
+
+			var body io.Reader = rr.Body
+			if tc.expectedCompressed {
+				if encoding := rr.Header().Get("Content-Encoding"); encoding != "gzip" {
+					t.Fatalf("expecting gzip Content-Encoding, got: %q", encoding)
+				}
+				gz, err := gzip.NewReader(rr.Body)
+				if err != nil {
+					t.Fatalf("unexpected error while reading gzip body: %v", err)
+				}
+				body = gz
+			} else if encoding := rr.Header().Get("Content-Encoding"); encoding != "" {
+				t.Fatalf("expecting uncompressed response, got Content-Encoding: %q", encoding)
+			}
+
+			var actual api.PaginatedResponse[api.BookV1]
+			if err := json.NewDecoder(body).Decode(&actual); err != nil {
+				t.Fatalf("unexpected error while parsing response body: %v", err)
+			}
+			if !reflect.DeepEqual(actual.Data, internal.BooksV1) {
+				t.Errorf("api returned unexpected body: got %v want %v", actual.Data, internal.BooksV1)
+			}
+		})
+	}
+}
+
+func TestCompressionValidate(t *testing.T) {
+	for _, level := range []int{gzip.DefaultCompression, gzip.NoCompression, gzip.BestSpeed, gzip.BestCompression} {
+		if err := (api.Compression{Level: level}).Validate(); err != nil {
+			t.Errorf("unexpected error occured for level %d: %v", level, err)
+		}
+	}
+	for _, level := range []int{-2, 10, 42} {
+		if err := (api.Compression{Level: level}).Validate(); err == nil {
+			t.Errorf("expecting level %d to be refused", level)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"fmt"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/contract"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type Version int
+
+const (
+	V1 Version = iota + 1
+	V2
+)
+
+const mediaTypeV2 = "application/vnd.pagination.v2+json"
+
+// BookV1 and CarV1 freeze the original wire format: Go-style keys and
+// timestamps rendered the way database/sql stringified them.
+type BookV1 struct {
+	Id        int
+	Title     string
+	Author    string
+	CreatedAt string
+}
+
+type CarV1 struct {
+	Id        int
+	Brand     string
+	Model     string
+	CreatedAt string
+}
+
+func negotiateVersion(r *http.Request) Version {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+			if strings.TrimSpace(mediaType) == mediaTypeV2 {
+				return V2
+			}
+		}
+	}
+	return V1
+}
+
+func booksV1(books []booksModels.Book) []BookV1 {
+	var result []BookV1
+	for _, b := range books {
+		result = append(result, BookV1{
+			Id:        b.Id,
+			Title:     b.Title,
+			Author:    b.Author,
+			CreatedAt: b.CreatedAt.Format(time.RFC3339Nano),
+		})
+	}
+	return result
+}
+
+func carsV1(cars []carsModels.Car) []CarV1 {
+	var result []CarV1
+	for _, c := range cars {
+		result = append(result, CarV1{
+			Id:        c.Id,
+			Brand:     c.Brand,
+			Model:     c.Model,
+			CreatedAt: c.CreatedAt.Format(time.RFC3339Nano),
+		})
+	}
+	return result
+}
+
+// offsetPage builds the page of items, params are appended to every link.
+func offsetPage[T any](r *http.Request, items []T, limit, offset int, params string) contract.PageResponse[T] {
+	link := func(offset int) string {
+		return fmt.Sprintf("%s?limit=%d&offset=%d%s", r.URL.Path, limit, offset, params)
+	}
+
+	links := contract.PageLinks{
+		Self:  link(offset),
+		First: link(0),
+	}
+	if offset > 0 {
+		prevOffset := offset - limit
+		if prevOffset < 0 {
+			prevOffset = 0
+		}
+		prev := link(prevOffset)
+		links.Prev = &prev
+	}
+	if hasNextPage(items, limit) {
+		next := link(offset + limit)
+		links.Next = &next
+	}
+
+	return contract.PageResponse[T]{
+		Data:  nonNil(items),
+		Links: links,
+		Meta:  contract.PageMeta{Limit: limit, Offset: &offset, Count: len(items)},
+	}
+}
+
+func cursorPage(r *http.Request, cars []carsModels.Car, cursor, limit int) contract.PageResponse[carsModels.Car] {
+	link := func(cursor int) string {
+		return fmt.Sprintf("%s?cursor=%d&limit=%d", r.URL.Path, cursor, limit)
+	}
+
+	links := contract.PageLinks{
+		Self:  link(cursor),
+		First: link(1),
+	}
+	if cursor > 1 {
+		prevCursor := cursor - limit
+		if prevCursor < 1 {
+			prevCursor = 1
+		}
+		prev := link(prevCursor)
+		links.Prev = &prev
+	}
+	if hasNextPage(cars, limit) {
+		next := link(cars[len(cars)-1].Id + 1)
+		links.Next = &next
+	}
+
+	return contract.PageResponse[carsModels.Car]{
+		Data:  nonNil(cars),
+		Links: links,
+		Meta:  contract.PageMeta{Limit: limit, Cursor: &cursor, Count: len(cars)},
+	}
+}
+
+func hasNextPage[T any](items []T, limit int) bool {
+	return len(items) > 0 && len(items) >= limit
+}
+
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/contract"
+	"github.com/krukkrz/pagination/pkg/problem"
+	"github.com/krukkrz/pagination/pkg/repository"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RangeCarRepository pages through the cars created within a time range,
+// oldest first, with the id breaking ties.
+type RangeCarRepository interface {
+	FetchCreated(ctx context.Context, rng repository.Range, after *repository.Position, limit int) ([]carsModels.Car, error)
+}
+
+// WithTimeRanges serves the cars created within a time range with
+// ?since=...&until=....
+func WithTimeRanges(cars RangeCarRepository) Option {
+	return func(s *Server) {
+		s.rangeCars = cars
+	}
+}
+
+// bounds tell which ends of a range are included, in interval notation.
+var bounds = map[string]repository.Range{
+	"[)": {},
+	"[]": {UntilInclusive: true},
+	"()": {SinceExclusive: true},
+	"(]": {SinceExclusive: true, UntilInclusive: true},
+}
+
+func (s Server) fetchCarsCreated(rw http.ResponseWriter, r *http.Request, version Version, format Format) {
+	if s.rangeCars == nil {
+		problem.Write(rw, http.StatusBadRequest, "time ranges are not enabled, use cursor")
+		return
+	}
+	query := r.URL.Query()
+
+	interval := query.Get("bounds")
+	if interval == "" {
+		interval = "[)"
+	}
+	rng, ok := bounds[interval]
+	if !ok {
+		problem.Write(rw, http.StatusBadRequest, "bounds must be one of [), [], () or (]")
+		return
+	}
+	var err error
+	if rng.Since, err = parseTimestamp(query.Get("since")); err != nil {
+		problem.Write(rw, http.StatusBadRequest, "since "+err.Error())
+		return
+	}
+	if rng.Until, err = parseTimestamp(query.Get("until")); err != nil {
+		problem.Write(rw, http.StatusBadRequest, "until "+err.Error())
+		return
+	}
+	if !rng.Since.IsZero() && !rng.Until.IsZero() && rng.Until.Before(rng.Since) {
+		problem.Write(rw, http.StatusBadRequest, "until must not be before since")
+		return
+	}
+
+	var after *repository.Position
+	if token := query.Get("after"); token != "" {
+		if after, err = decodePosition(token); err != nil {
+			problem.Write(rw, http.StatusBadRequest, "malformed after token")
+			return
+		}
+	}
+	limit, ok := s.parseLimit(rw, r)
+	if !ok {
+		return
+	}
+
+	log.Printf("received a request since: %s until: %s with limit: %d", query.Get("since"), query.Get("until"), limit)
+
+	// one car more tells whether there is a next page
+	cars, err := s.rangeCars.FetchCreated(r.Context(), rng, after, limit+1)
+	if err != nil {
+		log.Printf("error while fetching cars: %v", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var next *repository.Position
+	if len(cars) > limit {
+		cars = cars[:limit]
+		last := cars[limit-1]
+		next = &repository.Position{Time: last.CreatedAt, Key: last.Id}
+	}
+
+	if notModified(rw, r, pageETag(version, format, cars), s.cachePolicy.Page) {
+		return
+	}
+
+	link := func(after *repository.Position) string {
+		params := url.Values{"limit": {strconv.Itoa(limit)}}
+		if !rng.Since.IsZero() {
+			params.Set("since", rng.Since.Format(time.RFC3339Nano))
+		}
+		if !rng.Until.IsZero() {
+			params.Set("until", rng.Until.Format(time.RFC3339Nano))
+		}
+		if interval != "[)" {
+			params.Set("bounds", interval)
+		}
+		if after != nil {
+			params.Set("after", encodePosition(*after))
+		}
+		return r.URL.Path + "?" + params.Encode()
+	}
+	switch version {
+	case V2:
+		links := contract.PageLinks{Self: link(after), First: link(nil)}
+		if next != nil {
+			nextLink := link(next)
+			links.Next = &nextLink
+		}
+		page := contract.PageResponse[carsModels.Car]{
+			Data:  nonNil(cars),
+			Links: links,
+			Meta:  contract.PageMeta{Limit: limit, Count: len(cars)},
+		}
+		s.writePage(rw, r, format, page, page.Data, pageRelations(links))
+	default:
+		links := LinksResponse{First: link(nil)}
+		if next != nil {
+			links.Next = link(next)
+		}
+		data := carsV1(cars)
+		s.writePage(rw, r, format, PaginatedResponse[CarV1]{Data: data, Links: links}, data, links.relations())
+	}
+}
+
+// parseTimestamp parses an RFC 3339 timestamp, which always carries its
+// timezone, and returns it in UTC like the stored timestamps. An empty value
+// is an open bound.
+func parseTimestamp(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	// an unescaped + of an offset arrives as a space
+	value = strings.ReplaceAll(value, " ", "+")
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("must be an RFC 3339 timestamp with a timezone, e.g. 2023-03-23T20:00:00+01:00")
+	}
+	return t.UTC(), nil
+}
+
+func encodePosition(p repository.Position) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s,%d", p.Time.UTC().Format(time.RFC3339Nano), p.Key)))
+}
+
+func decodePosition(token string) (*repository.Position, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	timestamp, key, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return nil, fmt.Errorf("missing key")
+	}
+	p := &repository.Position{}
+	if p.Time, err = time.Parse(time.RFC3339Nano, timestamp); err != nil {
+		return nil, err
+	}
+	if p.Key, err = strconv.Atoi(key); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
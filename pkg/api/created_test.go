@@ -0,0 +1,112 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/api/internal"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/contract"
+	"github.com/krukkrz/pagination/pkg/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTimeRanges(t *testing.T) {
+	start := time.Date(2023, 3, 23, 19, 0, 0, 0, time.UTC)
+	cars := memory.NewCarRepository()
+	// every hour creates ten cars at the same time, and later ids are created
+	// earlier, so the id alone does not give the order
+	for i := 1; i <= 50; i++ {
+		createdAt := start.Add(time.Duration(4-(i-1)/10) * time.Hour)
+		cars.Insert(carsModels.Car{Id: i, Brand: fmt.Sprintf("brand %d", i), Model: fmt.Sprintf("model %d", i), CreatedAt: createdAt})
+	}
+	srv := api.NewServer(internal.BookRepositoryMockReturnBooks(10, 0, t), cars, api.WithTimeRanges(cars))
+
+	walk := func(t *testing.T, url string) []carsModels.Car {
+		t.Helper()
+		var all []carsModels.Car
+		for url != "" {
+			rr := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("api returned wrong status code for %s: got %v want %v", url, rr.Code, http.StatusOK)
+			}
+			var page contract.PageResponse[carsModels.Car]
+			if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+				t.Fatalf("unexpected error while parsing response body: %v", err)
+			}
+			all = append(all, page.Data...)
+			url = ""
+			if page.Links.Next != nil {
+				url = *page.Links.Next
+			}
+		}
+		return all
+	}
+
+	testCases := []struct {
+		name          string
+		url           string
+		expectedCount int
+		expectedFirst int
+	}{
+		{
+			name:          "walks every tie in pages smaller than a tie",
+			url:           "/v2/cars?since=2023-03-23T19:00:00Z&limit=3",
+			expectedCount: 50,
+			expectedFirst: 41,
+		},
+		{
+			name:          "includes since and excludes until",
+			url:           "/v2/cars?since=2023-03-23T20:00:00Z&until=2023-03-23T22:00:00Z&limit=4",
+			expectedCount: 20,
+			expectedFirst: 31,
+		},
+		{
+			name:          "takes the timezone of the input into account",
+			url:           "/v2/cars?since=2023-03-23T21:00:00+01:00&until=2023-03-23T23:00:00+01:00&limit=4",
+			expectedCount: 20,
+			expectedFirst: 31,
+		},
+		{
+			name:          "excludes since and includes until when asked",
+			url:           "/v2/cars?since=2023-03-23T20:00:00Z&until=2023-03-23T22:00:00Z&bounds=" + url.QueryEscape("(]") + "&limit=7",
+			expectedCount: 20,
+			expectedFirst: 21,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			all := walk(t, tc.url)
+			if len(all) != tc.expectedCount {
+				t.Fatalf("expecting %d cars, got: %d", tc.expectedCount, len(all))
+			}
+			if all[0].Id != tc.expectedFirst {
+				t.Errorf("expecting car %d first, got: %d", tc.expectedFirst, all[0].Id)
+			}
+			seen := make(map[int]bool)
+			for i, car := range all {
+				if seen[car.Id] {
+					t.Errorf("car %d returned twice", car.Id)
+				}
+				seen[car.Id] = true
+				if i > 0 && (car.CreatedAt.Before(all[i-1].CreatedAt) || car.CreatedAt.Equal(all[i-1].CreatedAt) && car.Id < all[i-1].Id) {
+					t.Errorf("car %d returned out of order after car %d", car.Id, all[i-1].Id)
+				}
+			}
+		})
+	}
+
+	t.Run("timestamps without timezone are rejected", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/v2/cars?since=2023-03-23T19:00:00&limit=3", nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("api returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/auth"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CachePolicy sets the max-age announced for each kind of page. The first page
+// changes whenever rows are added at the front, while a full cursor page is
+// bounded by its next cursor and only changes when its own rows do.
+type CachePolicy struct {
+	FirstPage      time.Duration
+	Page           time.Duration
+	ConsumedCursor time.Duration
+}
+
+var defaultCachePolicy = CachePolicy{
+	FirstPage:      5 * time.Second,
+	Page:           30 * time.Second,
+	ConsumedCursor: 5 * time.Minute,
+}
+
+func WithCachePolicy(policy CachePolicy) Option {
+	return func(s *Server) {
+		s.cachePolicy = policy
+	}
+}
+
+// pageETag hashes the representation version and format and the items on
+// the page as they are encoded, so any change of an item changes the ETag.
+func pageETag[T any](version Version, format Format, items []T) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "v%d.%s", version, format)
+	encoder := json.NewEncoder(h)
+	for _, item := range items {
+		encoder.Encode(item)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// gzipETag is the ETag of the gzipped body of a page with the given ETag, a
+// strong ETag tells byte-identical bodies apart.
+func gzipETag(etag string) string {
+	if !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + `-gzip"`
+}
+
+// notModified sets the validators of the page and answers with 304 when the
+// client already holds it, gzipped or not.
+func notModified(rw http.ResponseWriter, r *http.Request, etag string, maxAge time.Duration) bool {
+	visibility := "public"
+	if _, authenticated := auth.FromContext(r.Context()); authenticated {
+		visibility = "private"
+	}
+	rw.Header().Set("ETag", etag)
+	rw.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", visibility, int(maxAge.Seconds())))
+	matched, ok := etagMatches(r.Header.Get("If-None-Match"), etag, gzipETag(etag))
+	if !ok {
+		return false
+	}
+	rw.Header().Set("ETag", matched)
+	rw.Header().Add("Vary", "Accept")
+	rw.Header().Add("Vary", "Accept-Encoding")
+	rw.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches returns the ETag among etags which If-None-Match holds.
+func etagMatches(ifNoneMatch string, etags ...string) (string, bool) {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		for _, etag := range etags {
+			if candidate == "*" || candidate == etag {
+				return etag, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,173 @@
+package api_test
+
+import (
+	"compress/gzip"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/api/internal"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	"github.com/krukkrz/pagination/pkg/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConditionalGet(t *testing.T) {
+	srv := api.NewServer(
+		internal.BookRepositoryMockReturnBooks(10, 0, t),
+		internal.CarRepositoryMockReturnCars(10, 1, t),
+	)
+
+	get := func(url, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := get("/v1/books?limit=10&offset=0", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expecting 200 with an ETag, got: %d and %q", first.Code, etag)
+	}
+
+	testCases := []struct {
+		name           string
+		url            string
+		ifNoneMatch    string
+		expectedStatus int
+	}{
+		{
+			name:           "returns 304 when the page did not change",
+			url:            "/v1/books?limit=10&offset=0",
+			ifNoneMatch:    etag,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "matches an ETag from a list, ignoring the weak prefix",
+			url:            "/v1/books?limit=10&offset=0",
+			ifNoneMatch:    `"stale", W/` + etag,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "returns the page when the ETag is stale",
+			url:            "/v1/books?limit=10&offset=0",
+			ifNoneMatch:    `"stale"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "another representation of the same page has another ETag",
+			url:            "/v2/books?limit=10&offset=0",
+			ifNoneMatch:    etag,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := get(tc.url, tc.ifNoneMatch)
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("api returned wrong status code: got %v want %v", rr.Code, tc.expectedStatus)
+			}
+			if tc.expectedStatus == http.StatusNotModified && rr.Body.Len() != 0 {
+				t.Errorf("expecting empty body for 304, got: %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	policy := api.CachePolicy{FirstPage: 2 * time.Second, Page: 20 * time.Second, ConsumedCursor: 200 * time.Second}
+
+	testCases := []struct {
+		name     string
+		url      string
+		books    api.BookRepository
+		cars     api.CarRepository
+		expected string
+	}{
+		{
+			name:     "first offset page has the short TTL",
+			url:      "/v1/books?limit=10&offset=0",
+			books:    internal.BookRepositoryMockReturnBooks(10, 0, t),
+			expected: "public, max-age=2",
+		},
+		{
+			name:     "further offset pages have the default TTL",
+			url:      "/v1/books?limit=10&offset=10",
+			books:    internal.BookRepositoryMockReturnBooks(10, 10, t),
+			expected: "public, max-age=20",
+		},
+		{
+			name:     "first cursor page has the short TTL",
+			url:      "/v1/cars?cursor=1&limit=10",
+			cars:     internal.CarRepositoryMockReturnCars(10, 1, t),
+			expected: "public, max-age=2",
+		},
+		{
+			name:     "fully consumed cursor page has the long TTL",
+			url:      "/v1/cars?cursor=11&limit=10",
+			cars:     internal.CarRepositoryMockReturnCars(10, 11, t),
+			expected: "public, max-age=200",
+		},
+		{
+			name:     "partial cursor page has the default TTL",
+			url:      "/v1/cars?cursor=11&limit=20",
+			cars:     internal.CarRepositoryMockReturnCars(20, 11, t),
+			expected: "public, max-age=20",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.books == nil {
+				tc.books = internal.BookServiceMockReturnError()
+			}
+			if tc.cars == nil {
+				tc.cars = internal.CarRepositoryMockReturnError()
+			}
+			srv := api.NewServer(tc.books, tc.cars, api.WithCachePolicy(policy))
+
+			rr := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", tc.url, nil))
+
+			if actual := rr.Header().Get("Cache-Control"); actual != tc.expected {
+				t.Errorf("unexpected Cache-Control, got: %s, expected: %s", actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestETagChanges(t *testing.T) {
+	books := memory.NewBookRepository()
+	createdAt := time.Date(2023, 3, 23, 19, 0, 0, 0, time.UTC)
+	books.Insert(booksModels.Book{Id: 1, Title: "first", Author: "author", CreatedAt: createdAt})
+	srv := api.NewServer(books, internal.CarRepositoryMockReturnError(), api.WithCompression(api.Compression{Level: gzip.DefaultCompression, MinSize: 1}))
+
+	get := func(acceptEncoding, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/v2/books?limit=10&offset=0", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, req)
+		return rr
+	}
+
+	plain := get("", "").Header().Get("ETag")
+	gzipped := get("gzip", "")
+	if etag := gzipped.Header().Get("ETag"); gzipped.Header().Get("Content-Encoding") != "gzip" || etag == plain || !strings.HasSuffix(etag, `-gzip"`) {
+		t.Errorf("expecting gzipped page to have its own ETag, got: %s and %s", etag, plain)
+	}
+	if rr := get("gzip", gzipped.Header().Get("ETag")); rr.Code != http.StatusNotModified || rr.Header().Get("ETag") != gzipped.Header().Get("ETag") {
+		t.Errorf("expecting 304 for the gzipped ETag, got: %d with %s", rr.Code, rr.Header().Get("ETag"))
+	}
+
+	books.Update(booksModels.Book{Id: 1, Title: "first edition", Author: "author", CreatedAt: createdAt})
+	if rr := get("", plain); rr.Code != http.StatusOK || rr.Header().Get("ETag") == plain {
+		t.Errorf("expecting an updated page to have another ETag, got: %d with %s", rr.Code, rr.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+)
+
+// exportChunkSize is the number of rows read by a single query of an export,
+// and the number of rows written between flushes.
+const exportChunkSize = 500
+
+type BookExporter interface {
+	Export(ctx context.Context, chunkSize int, fn func(booksModels.Book) error) error
+}
+
+type CarExporter interface {
+	Export(ctx context.Context, chunkSize int, fn func(carsModels.Car) error) error
+}
+
+// WithExport serves the whole collections of books and cars on /books/export
+// and /cars/export.
+func WithExport(books BookExporter, cars CarExporter) Option {
+	return func(s *Server) {
+		s.bookExporter = books
+		s.carExporter = cars
+	}
+}
+
+func (s Server) ExportBooks(rw http.ResponseWriter, r *http.Request) {
+	export(s, rw, r, s.bookExporter.Export)
+}
+
+func (s Server) ExportCars(rw http.ResponseWriter, r *http.Request) {
+	export(s, rw, r, s.carExporter.Export)
+}
+
+// export streams every item as NDJSON, or as CSV when asked for. Items are
+// written as they are read and flushed once per chunk, so the response never
+// holds more than a chunk.
+func export[T any](s Server, rw http.ResponseWriter, r *http.Request, fetch func(context.Context, int, func(T) error) error) {
+	log.Printf("received an export request: %s", r.RequestURI)
+	if !validateGetRequest(rw, r) {
+		return
+	}
+
+	format, ok := negotiateFormat(r)
+	if !ok {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if format == JSON {
+		format = NDJSON
+	}
+
+	rw.Header().Set("Content-Type", contentType(format))
+	rw.Header().Add("Vary", "Accept")
+	w := s.compress(rw, r)
+	encoder := newItemEncoder(w, format, reflect.TypeOf((*T)(nil)).Elem())
+	count := 0
+	err := fetch(r.Context(), exportChunkSize, func(item T) error {
+		if err := encoder.encode(reflect.ValueOf(item)); err != nil {
+			return err
+		}
+		count++
+		if count%exportChunkSize == 0 {
+			return flush(w, encoder)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("error while exporting after %d items: %v", count, err)
+		if count == 0 {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		// the rows written so far may still be buffered with the status unsent,
+		// flushing sends them and aborting then tells the client the export is
+		// incomplete
+		flush(w, encoder)
+		panic(http.ErrAbortHandler)
+	}
+
+	if err := encoder.flush(); err != nil {
+		log.Printf("error while exporting: %v", err)
+	}
+	w.Close()
+	log.Printf("exported %d items", count)
+}
+
+func flush(w io.Writer, encoder itemEncoder) error {
+	if err := encoder.flush(); err != nil {
+		return err
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
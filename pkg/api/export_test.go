@@ -0,0 +1,146 @@
+package api_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/api/internal"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/storage/memory"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExport(t *testing.T) {
+	createdAt := time.Date(2023, 3, 23, 19, 0, 0, 0, time.UTC)
+	books := memory.NewBookRepository()
+	cars := memory.NewCarRepository()
+	for i := 1; i <= 1200; i++ {
+		books.Insert(booksModels.Book{Id: i, Title: fmt.Sprintf("title %d", i), Author: fmt.Sprintf("author %d", i), CreatedAt: createdAt})
+		cars.Insert(carsModels.Car{Id: i, Brand: fmt.Sprintf("brand %d", i), Model: fmt.Sprintf("model %d", i), CreatedAt: createdAt})
+	}
+	srv := api.NewServer(
+		internal.BookRepositoryMockReturnBooks(10, 0, t),
+		internal.CarRepositoryMockReturnCars(10, 1, t),
+		api.WithExport(books, cars),
+	)
+
+	t.Run("books are exported as ndjson by default", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/books/export", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("api returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if !rr.Flushed {
+			t.Errorf("expecting export to be flushed while streaming")
+		}
+
+		count := 0
+		scanner := bufio.NewScanner(rr.Body)
+		for scanner.Scan() {
+			var book booksModels.Book
+			if err := json.Unmarshal(scanner.Bytes(), &book); err != nil {
+				t.Fatalf("unexpected error while parsing line %q: %v", scanner.Text(), err)
+			}
+			count++
+			if book.Id != count {
+				t.Fatalf("expecting book %d next, got: %d", count, book.Id)
+			}
+		}
+		if count != 1200 {
+			t.Errorf("expecting 1200 books, got: %d", count)
+		}
+	})
+
+	t.Run("cars are exported as csv", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/cars/export", nil)
+		req.Header.Set("Accept", "text/csv")
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, req)
+
+		records, err := csv.NewReader(rr.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("unexpected error while parsing response body: %v", err)
+		}
+		if len(records) != 1201 || records[0][0] != "id" || records[1200][0] != "1200" {
+			t.Errorf("expecting header and 1200 cars, got %d records ending with %v", len(records), records[len(records)-1])
+		}
+	})
+
+	t.Run("export is not served without exporters", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		api.NewServer(internal.BookServiceMockReturnError(), internal.CarRepositoryMockReturnCars(10, 1, t)).Handler().
+			ServeHTTP(rr, httptest.NewRequest("GET", "/books/export", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("api returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+}
+
+// failingBookExporter exports count books and fails.
+type failingBookExporter struct {
+	count int
+}
+
+func (e failingBookExporter) Export(ctx context.Context, chunkSize int, fn func(booksModels.Book) error) error {
+	for i := 1; i <= e.count; i++ {
+		if err := fn(booksModels.Book{Id: i, Title: "title", Author: "author"}); err != nil {
+			return err
+		}
+	}
+	return errors.New("connection lost")
+}
+
+func TestExportFailure(t *testing.T) {
+	testCases := []struct {
+		name      string
+		count     int
+		accept    string
+		status    int
+		truncated bool
+	}{
+		{name: "fails before the first book", count: 0, status: http.StatusInternalServerError},
+		{name: "aborts after books within the first chunk", count: 3, status: http.StatusOK, truncated: true},
+		{name: "aborts csv after books within the first chunk", count: 3, accept: "text/csv", status: http.StatusOK, truncated: true},
+		{name: "aborts after a flushed chunk", count: 700, status: http.StatusOK, truncated: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(api.NewServer(
+				internal.BookRepositoryMockReturnBooks(10, 0, t),
+				internal.CarRepositoryMockReturnCars(10, 1, t),
+				api.WithExport(failingBookExporter{count: tc.count}, memory.NewCarRepository()),
+			).Handler())
+			defer srv.Close()
+
+			req, err := http.NewRequest("GET", srv.URL+"/books/export", nil)
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tc.status {
+				t.Errorf("api returned wrong status code: got %v want %v", resp.StatusCode, tc.status)
+			}
+			_, err = io.ReadAll(resp.Body)
+			if truncated := err != nil; truncated != tc.truncated {
+				t.Errorf("expecting truncated body: %v, got error: %v", tc.truncated, err)
+			}
+		})
+	}
+}
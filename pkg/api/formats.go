@@ -0,0 +1,198 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/contract"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	JSON   Format = "json"
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+)
+
+var formatMediaTypes = map[string]Format{
+	"application/json":     JSON,
+	"text/csv":             CSV,
+	"application/x-ndjson": NDJSON,
+}
+
+// negotiateFormat reads the format from the format parameter, or from the
+// Accept header when there is none. It reports false for unknown formats.
+func negotiateFormat(r *http.Request) (Format, bool) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		switch Format(format) {
+		case JSON, CSV, NDJSON:
+			return Format(format), true
+		}
+		return "", false
+	}
+
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+			if format, ok := formatMediaTypes[strings.TrimSpace(mediaType)]; ok {
+				return format, true
+			}
+		}
+	}
+	return JSON, true
+}
+
+type link struct {
+	rel string
+	url string
+}
+
+func (l LinksResponse) relations() []link {
+	return []link{{"first", l.First}, {"prev", l.Prev}, {"next", l.Next}}
+}
+
+func pageRelations(l contract.PageLinks) []link {
+	relations := []link{{"self", l.Self}, {"first", l.First}}
+	if l.Prev != nil {
+		relations = append(relations, link{"prev", *l.Prev})
+	}
+	if l.Next != nil {
+		relations = append(relations, link{"next", *l.Next})
+	}
+	return relations
+}
+
+// writePage sends the page in the negotiated format. JSON sends the whole
+// envelope, while CSV and NDJSON send only the items and move the links to
+// the Link header.
+func (s Server) writePage(rw http.ResponseWriter, r *http.Request, format Format, envelope interface{}, items interface{}, links []link) {
+	if format == JSON {
+		s.encodeJsonResponse(rw, r, envelope)
+		return
+	}
+
+	for _, l := range links {
+		rw.Header().Add("Link", fmt.Sprintf(`<%s>; rel="%s"`, l.url, l.rel))
+	}
+	rw.Header().Add("Vary", "Accept")
+	rw.Header().Set("Content-Type", contentType(format))
+	w := s.compress(rw, r)
+	defer w.Close()
+	encoder := newItemEncoder(w, format, reflect.TypeOf(items).Elem())
+	value := reflect.ValueOf(items)
+	for i := 0; i < value.Len(); i++ {
+		if err := encoder.encode(value.Index(i)); err != nil {
+			return
+		}
+	}
+	encoder.flush()
+}
+
+func contentType(format Format) string {
+	switch format {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case NDJSON:
+		return "application/x-ndjson"
+	}
+	return "application/json"
+}
+
+// itemEncoder writes items one by one in a format without an envelope.
+type itemEncoder interface {
+	encode(item reflect.Value) error
+	// flush writes buffered items to the underlying writer.
+	flush() error
+}
+
+func newItemEncoder(w io.Writer, format Format, itemType reflect.Type) itemEncoder {
+	if format == CSV {
+		return newCsvEncoder(w, itemType)
+	}
+	return ndjsonEncoder{json.NewEncoder(w)}
+}
+
+type ndjsonEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e ndjsonEncoder) encode(item reflect.Value) error {
+	return e.encoder.Encode(item.Interface())
+}
+
+func (e ndjsonEncoder) flush() error {
+	return nil
+}
+
+// csvEncoder writes a header with the JSON names of the fields, in the order
+// the fields are declared, followed by one record per item.
+type csvEncoder struct {
+	writer    *csv.Writer
+	headerErr error
+}
+
+func newCsvEncoder(w io.Writer, itemType reflect.Type) *csvEncoder {
+	writer := csv.NewWriter(w)
+	return &csvEncoder{
+		writer:    writer,
+		headerErr: writer.Write(csvHeader(itemType)),
+	}
+}
+
+func (e *csvEncoder) encode(item reflect.Value) error {
+	if e.headerErr != nil {
+		return e.headerErr
+	}
+	return e.writer.Write(csvRecord(item))
+}
+
+func (e *csvEncoder) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func csvHeader(t reflect.Type) []string {
+	var header []string
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := csvName(t.Field(i)); ok {
+			header = append(header, name)
+		}
+	}
+	return header
+}
+
+func csvRecord(item reflect.Value) []string {
+	var record []string
+	for i := 0; i < item.NumField(); i++ {
+		if _, ok := csvName(item.Type().Field(i)); !ok {
+			continue
+		}
+		switch field := item.Field(i).Interface().(type) {
+		case time.Time:
+			record = append(record, field.UTC().Format(time.RFC3339Nano))
+		default:
+			record = append(record, fmt.Sprint(field))
+		}
+	}
+	return record
+}
+
+func csvName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	}
+	return name, true
+}
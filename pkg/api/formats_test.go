@@ -0,0 +1,97 @@
+package api_test
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/api/internal"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormats(t *testing.T) {
+	srv := api.NewServer(
+		internal.BookRepositoryMockReturnBooks(10, 0, t),
+		internal.CarRepositoryMockReturnCars(10, 1, t),
+	)
+
+	get := func(url, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("csv has a stable header and links in the Link header", func(t *testing.T) {
+		rr := get("/v2/books?limit=10&offset=0&format=csv", "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("api returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if contentType := rr.Header().Get("Content-Type"); contentType != "text/csv; charset=utf-8" {
+			t.Errorf("unexpected Content-Type: %s", contentType)
+		}
+
+		records, err := csv.NewReader(rr.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("unexpected error while parsing response body: %v", err)
+		}
+		if expected := []string{"id", "title", "author", "created_at"}; !reflect.DeepEqual(records[0], expected) {
+			t.Errorf("unexpected csv header, got: %v, expected: %v", records[0], expected)
+		}
+		if expected := []string{"1", "title 1", "author 1", "2023-03-23T19:00:00.62337Z"}; !reflect.DeepEqual(records[1], expected) {
+			t.Errorf("unexpected csv record, got: %v, expected: %v", records[1], expected)
+		}
+		if len(records) != 11 {
+			t.Errorf("expecting header and 10 records, got: %d", len(records))
+		}
+
+		links := strings.Join(rr.Header().Values("Link"), ", ")
+		if !strings.Contains(links, `</v2/books?limit=10&offset=10>; rel="next"`) {
+			t.Errorf("expecting next link in Link header, got: %s", links)
+		}
+	})
+
+	t.Run("ndjson is negotiated with the Accept header", func(t *testing.T) {
+		rr := get("/v1/cars?cursor=1&limit=10", "application/x-ndjson")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("api returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+
+		var actual []api.CarV1
+		scanner := bufio.NewScanner(rr.Body)
+		for scanner.Scan() {
+			var car api.CarV1
+			if err := json.Unmarshal(scanner.Bytes(), &car); err != nil {
+				t.Fatalf("unexpected error while parsing line %q: %v", scanner.Text(), err)
+			}
+			actual = append(actual, car)
+		}
+		if !reflect.DeepEqual(actual, internal.CarsV1) {
+			t.Errorf("api returned unexpected body: got %v want %v", actual, internal.CarsV1)
+		}
+		if links := rr.Header().Values("Link"); len(links) != 3 {
+			t.Errorf("expecting first, prev and next links, got: %v", links)
+		}
+	})
+
+	t.Run("representations have different ETags", func(t *testing.T) {
+		jsonETag := get("/v2/books?limit=10&offset=0", "").Header().Get("ETag")
+		csvETag := get("/v2/books?limit=10&offset=0", "text/csv").Header().Get("ETag")
+		if jsonETag == csvETag {
+			t.Errorf("expecting json and csv pages to have different ETags, got: %s", jsonETag)
+		}
+	})
+
+	t.Run("unknown format is rejected", func(t *testing.T) {
+		if rr := get("/v2/books?limit=10&offset=0&format=xml", ""); rr.Code != http.StatusBadRequest {
+			t.Errorf("api returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+}
@@ -1,28 +1,69 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/krukkrz/pagination/pkg/auth"
 	booksModels "github.com/krukkrz/pagination/pkg/books/model"
 	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/checkpoint"
+	"github.com/krukkrz/pagination/pkg/cors"
+	"github.com/krukkrz/pagination/pkg/problem"
+	"github.com/krukkrz/pagination/pkg/ratelimit"
+	"github.com/krukkrz/pagination/pkg/scan"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type BookRepository interface {
-	FetchAll(limit, offset int) ([]booksModels.Book, error)
+	FetchAll(ctx context.Context, limit, offset int) ([]booksModels.Book, error)
 }
 
 type CarRepository interface {
-	FetchAll(cursor, limit int) ([]carsModels.Car, error)
+	FetchAll(ctx context.Context, cursor, limit int) ([]carsModels.Car, error)
 }
 
 type Server struct {
 	bookRepository BookRepository
 	carRepository  CarRepository
+	deprecation    time.Time
+	sunset         time.Time
+	cachePolicy    CachePolicy
+	rateLimiter    *ratelimit.Limiter
+	keyStore       *auth.Store
+	cors           *cors.Config
+	compression    Compression
+	bookExporter   BookExporter
+	carExporter    CarExporter
+	snapshots      SnapshotBookRepository
+	snapshotTTL    time.Duration
+	checkpoints    *checkpoint.Index
+	seekCars       SeekCarRepository
+	aroundCars     AroundCarRepository
+	countCars      CountCarRepository
+	rangeCars      RangeCarRepository
+	carScans       *scan.Manager[carsModels.Car]
+	bookChanges    BookChangeFeed
+	carChanges     CarChangeFeed
+	maxLimit       int
 }
 
+// defaultMaxLimit is the largest page a client may ask for.
+const defaultMaxLimit = 1000
+
+// WithMaxLimit sets the largest page a client may ask for, larger limits are
+// rejected.
+func WithMaxLimit(limit int) Option {
+	return func(s *Server) {
+		s.maxLimit = limit
+	}
+}
+
+type Option func(*Server)
+
 type PaginatedResponse[T any] struct {
 	Data  []T           `json:"data"`
 	Links LinksResponse `json:"links"`
@@ -34,104 +75,252 @@ type LinksResponse struct {
 	First string `json:"first"`
 }
 
-func NewServer(bookRepository BookRepository, carRepository CarRepository) *Server {
-	return &Server{
+func NewServer(bookRepository BookRepository, carRepository CarRepository, opts ...Option) *Server {
+	s := &Server{
 		bookRepository: bookRepository,
 		carRepository:  carRepository,
+		deprecation:    defaultDeprecation,
+		sunset:         defaultSunset,
+		cachePolicy:    defaultCachePolicy,
+		compression:    defaultCompression,
+		maxLimit:       defaultMaxLimit,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
-func (s Server) Start(port string) error {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/books", s.FetchAllBooks)
-	mux.HandleFunc("/cars", s.FetchAllCars)
+// Start serves the API until ctx is done, then waits for the requests in
+// flight before returning.
+func (s Server) Start(ctx context.Context, port string) error {
+	server := &http.Server{Addr: port, Handler: s.Handler()}
+	go func() {
+		<-ctx.Done()
+		log.Println("Shutting down...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
 	log.Printf("Application is ready to listen on port: %s", port)
-	return http.ListenAndServe(port, mux)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Close closes the open scans, which roll back their transactions. It should
+// be called before the database is closed.
+func (s Server) Close() {
+	if s.carScans != nil {
+		s.carScans.Shutdown()
+	}
 }
 
 func (s Server) FetchAllBooks(rw http.ResponseWriter, r *http.Request) {
+	s.fetchAllBooks(rw, r, negotiateVersion(r))
+}
+
+func (s Server) FetchAllCars(rw http.ResponseWriter, r *http.Request) {
+	s.fetchAllCars(rw, r, negotiateVersion(r))
+}
+
+func (s Server) fetchAllBooks(rw http.ResponseWriter, r *http.Request, version Version) {
 	log.Printf("received a request: %s", r.RequestURI)
-	validateGetRequest(rw, r)
+	if !validateGetRequest(rw, r) {
+		return
+	}
 
-	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
-	if err != nil {
+	format, ok := negotiateFormat(r)
+	if !ok {
 		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	limit, ok := s.parseLimit(rw, r)
+	if !ok {
+		return
 	}
 
 	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
-	if err != nil {
+	if err != nil || offset < 0 {
 		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	snap, ok := s.openSnapshot(rw, r)
+	if !ok {
+		return
 	}
 
 	log.Printf("received a request with limit: %d and offset: %d", limit, offset)
 
-	books, err := s.bookRepository.FetchAll(limit, offset)
+	var books []booksModels.Book
+	if snap != nil {
+		books, err = s.snapshots.FetchAllAsOf(r.Context(), snap.asOf, limit, offset)
+		rw.Header().Set("Snapshot-Expires", snap.expires.Format(http.TimeFormat))
+	} else {
+		books, err = s.bookRepository.FetchAll(r.Context(), limit, offset)
+	}
 	if err != nil {
+		log.Printf("error while fetching books: %v", err)
 		rw.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
-	nextOffset, prevOffset := offset+limit, offset-limit
-	if prevOffset < 0 {
-		prevOffset = 0
+	maxAge := s.cachePolicy.Page
+	if offset == 0 {
+		maxAge = s.cachePolicy.FirstPage
 	}
-	urlFormat := "%s?limit=%d&offset=%d"
-	response := PaginatedResponse[booksModels.Book]{
-		Data: books,
-		Links: LinksResponse{
-			Next:  fmt.Sprintf(urlFormat, r.URL.Path, limit, nextOffset),
-			Prev:  fmt.Sprintf(urlFormat, r.URL.Path, limit, prevOffset),
-			First: fmt.Sprintf(urlFormat, r.URL.Path, limit, 0),
-		},
+	if notModified(rw, r, pageETag(version, format, books), maxAge) {
+		return
 	}
 
-	encodeJsonResponse(rw, response)
+	switch version {
+	case V2:
+		page := offsetPage(r, books, limit, offset, snap.params())
+		page.Meta.Snapshot = snap.meta()
+		s.writePage(rw, r, format, page, page.Data, pageRelations(page.Links))
+	default:
+		nextOffset, prevOffset := offset+limit, offset-limit
+		if prevOffset < 0 {
+			prevOffset = 0
+		}
+		urlFormat := "%s?limit=%d&offset=%d%s"
+		links := LinksResponse{
+			Next:  fmt.Sprintf(urlFormat, r.URL.Path, limit, nextOffset, snap.params()),
+			Prev:  fmt.Sprintf(urlFormat, r.URL.Path, limit, prevOffset, snap.params()),
+			First: fmt.Sprintf(urlFormat, r.URL.Path, limit, 0, snap.params()),
+		}
+		data := booksV1(books)
+		s.writePage(rw, r, format, PaginatedResponse[BookV1]{Data: data, Links: links}, data, links.relations())
+	}
 }
 
-func (s Server) FetchAllCars(rw http.ResponseWriter, r *http.Request) {
+func (s Server) fetchAllCars(rw http.ResponseWriter, r *http.Request, version Version) {
 	log.Printf("received a request: %s", r.RequestURI)
-	validateGetRequest(rw, r)
+	if !validateGetRequest(rw, r) {
+		return
+	}
 
-	cursor, err := strconv.Atoi(r.URL.Query().Get("cursor"))
-	if err != nil {
+	format, ok := negotiateFormat(r)
+	if !ok {
 		rw.WriteHeader(http.StatusBadRequest)
+		return
 	}
 
-	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
-	if err != nil {
-		rw.WriteHeader(http.StatusBadRequest)
+	if r.URL.Query().Has("around") {
+		s.fetchCarsAround(rw, r, version, format)
+		return
+	}
+	if r.URL.Query().Has("since") || r.URL.Query().Has("until") {
+		s.fetchCarsCreated(rw, r, version, format)
+		return
 	}
 
-	log.Printf("received a request with cursor: %d and limit: %d", cursor, limit)
+	// a numbered page is sought from a checkpoint instead of a cursor
+	var number, cursor int
+	var err error
+	if r.URL.Query().Has("page") {
+		number, err = strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil || number < 1 {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	} else {
+		cursor, err = strconv.Atoi(r.URL.Query().Get("cursor"))
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
 
-	cars, err := s.carRepository.FetchAll(cursor, limit)
-	if err != nil {
-		rw.WriteHeader(http.StatusInternalServerError)
+	limit, ok := s.parseLimit(rw, r)
+	if !ok {
+		return
 	}
 
-	nextCursor, prevCursor := cursor+limit, cursor-limit
-	if prevCursor < 1 {
-		prevCursor = 1
+	var cars []carsModels.Car
+	if number > 0 {
+		log.Printf("received a request with page: %d and limit: %d", number, limit)
+		var ok bool
+		if cars, ok = s.seekPage(rw, r, number, limit); !ok {
+			return
+		}
+		if len(cars) > 0 {
+			cursor = cars[0].Id
+		}
+	} else {
+		log.Printf("received a request with cursor: %d and limit: %d", cursor, limit)
+		cars, err = s.carRepository.FetchAll(r.Context(), cursor, limit)
+		if err != nil {
+			log.Printf("error while fetching cars: %v", err)
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 	}
-	urlFormat := "%s?cursor=%d&limit=%d"
-	response := PaginatedResponse[carsModels.Car]{
-		Data: cars,
-		Links: LinksResponse{
+
+	maxAge := s.cachePolicy.Page
+	switch {
+	case cursor <= 1:
+		maxAge = s.cachePolicy.FirstPage
+	case hasNextPage(cars, limit):
+		maxAge = s.cachePolicy.ConsumedCursor
+	}
+	if notModified(rw, r, pageETag(version, format, cars), maxAge) {
+		return
+	}
+
+	switch version {
+	case V2:
+		page := cursorPage(r, cars, cursor, limit)
+		if number > 0 {
+			numberedPage(r, &page, number, limit)
+		}
+		s.writePage(rw, r, format, page, page.Data, pageRelations(page.Links))
+	default:
+		nextCursor, prevCursor := cursor+limit, cursor-limit
+		if prevCursor < 1 {
+			prevCursor = 1
+		}
+		urlFormat := "%s?cursor=%d&limit=%d"
+		links := LinksResponse{
 			Next:  fmt.Sprintf(urlFormat, r.URL.Path, nextCursor, limit),
 			Prev:  fmt.Sprintf(urlFormat, r.URL.Path, prevCursor, limit),
 			First: fmt.Sprintf(urlFormat, r.URL.Path, 1, limit),
-		},
+		}
+		data := carsV1(cars)
+		s.writePage(rw, r, format, PaginatedResponse[CarV1]{Data: data, Links: links}, data, links.relations())
 	}
-
-	encodeJsonResponse(rw, response)
 }
 
-func encodeJsonResponse(rw http.ResponseWriter, response interface{}) {
+func (s Server) encodeJsonResponse(rw http.ResponseWriter, r *http.Request, response interface{}) {
 	rw.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(rw).Encode(response)
+	rw.Header().Add("Vary", "Accept")
+	w := s.compress(rw, r)
+	defer w.Close()
+	json.NewEncoder(w).Encode(response)
 }
 
-func validateGetRequest(rw http.ResponseWriter, r *http.Request) {
+func validateGetRequest(rw http.ResponseWriter, r *http.Request) bool {
 	if r.Method != "GET" {
 		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return false
+	}
+	return true
+}
+
+// parseLimit reads the limit of a page, which has to be between 1 and the
+// maximum limit. Problems are written to the response.
+func (s Server) parseLimit(rw http.ResponseWriter, r *http.Request) (int, bool) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		rw.WriteHeader(http.StatusBadRequest)
+		return 0, false
+	}
+	if limit > s.maxLimit {
+		problem.Write(rw, http.StatusBadRequest, fmt.Sprintf("limit must be at most %d", s.maxLimit))
+		return 0, false
 	}
+	return limit, true
 }
@@ -5,8 +5,6 @@ import (
 	"fmt"
 	"github.com/krukkrz/pagination/pkg/api"
 	"github.com/krukkrz/pagination/pkg/api/internal"
-	booksModels "github.com/krukkrz/pagination/pkg/books/model"
-	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
@@ -22,7 +20,7 @@ func TestFetchAllBooks(t *testing.T) {
 		method          string
 		expectedStatus  int
 		serviceError    bool
-		expectedBooks   []booksModels.Book
+		expectedBooks   []api.BookV1
 		bookServiceMock api.BookRepository
 		prevOffset      int
 		nextOffset      int
@@ -70,7 +68,7 @@ func TestFetchAllBooks(t *testing.T) {
 			prevOffset:      0,
 			nextOffset:      10,
 			bookServiceMock: internal.BookRepositoryMockReturnBooks(10, 0, t),
-			expectedBooks:   internal.Books,
+			expectedBooks:   internal.BooksV1,
 			expectedStatus:  http.StatusOK,
 		},
 		{
@@ -80,7 +78,7 @@ func TestFetchAllBooks(t *testing.T) {
 			prevOffset:      0,
 			nextOffset:      9,
 			bookServiceMock: internal.BookRepositoryMockReturnBooks(5, 4, t),
-			expectedBooks:   internal.Books,
+			expectedBooks:   internal.BooksV1,
 			expectedStatus:  http.StatusOK,
 		},
 		{
@@ -90,7 +88,7 @@ func TestFetchAllBooks(t *testing.T) {
 			prevOffset:      4,
 			nextOffset:      14,
 			bookServiceMock: internal.BookRepositoryMockReturnBooks(5, 9, t),
-			expectedBooks:   internal.Books,
+			expectedBooks:   internal.BooksV1,
 			expectedStatus:  http.StatusOK,
 		},
 	}
@@ -122,7 +120,7 @@ func TestFetchAllBooks(t *testing.T) {
 			}
 
 			if tc.expectedBooks != nil {
-				var actual api.PaginatedResponse[booksModels.Book]
+				var actual api.PaginatedResponse[api.BookV1]
 				if err := json.NewDecoder(rr.Body).Decode(&actual); err != nil {
 					t.Fatalf("unexpected error while parsing response body: %v", err)
 				}
@@ -159,7 +157,7 @@ func TestFetchAllCars(t *testing.T) {
 		method            string
 		expectedStatus    int
 		serviceError      bool
-		expectedCars      []carsModels.Car
+		expectedCars      []api.CarV1
 		carRepositoryMock api.CarRepository
 		prevCursor        int
 		nextCursor        int
@@ -207,7 +205,7 @@ func TestFetchAllCars(t *testing.T) {
 			prevCursor:        1,
 			nextCursor:        11,
 			carRepositoryMock: internal.CarRepositoryMockReturnCars(10, 1, t),
-			expectedCars:      internal.Cars,
+			expectedCars:      internal.CarsV1,
 			expectedStatus:    http.StatusOK,
 		},
 		{
@@ -217,7 +215,7 @@ func TestFetchAllCars(t *testing.T) {
 			prevCursor:        1,
 			nextCursor:        9,
 			carRepositoryMock: internal.CarRepositoryMockReturnCars(5, 4, t),
-			expectedCars:      internal.Cars,
+			expectedCars:      internal.CarsV1,
 			expectedStatus:    http.StatusOK,
 		},
 		{
@@ -227,7 +225,7 @@ func TestFetchAllCars(t *testing.T) {
 			prevCursor:        4,
 			nextCursor:        14,
 			carRepositoryMock: internal.CarRepositoryMockReturnCars(5, 9, t),
-			expectedCars:      internal.Cars,
+			expectedCars:      internal.CarsV1,
 			expectedStatus:    http.StatusOK,
 		},
 	}
@@ -259,7 +257,7 @@ func TestFetchAllCars(t *testing.T) {
 			}
 
 			if tc.expectedCars != nil {
-				var actual api.PaginatedResponse[carsModels.Car]
+				var actual api.PaginatedResponse[api.CarV1]
 				if err := json.NewDecoder(rr.Body).Decode(&actual); err != nil {
 					t.Fatalf("unexpected error while parsing response body: %v", err)
 				}
@@ -287,6 +285,81 @@ func TestFetchAllCars(t *testing.T) {
 	}
 }
 
+func TestResponseVersions(t *testing.T) {
+	testCases := []struct {
+		name         string
+		accept       string
+		expectedBook map[string]interface{}
+		expectedCar  map[string]interface{}
+	}{
+		{
+			name:   "returns v1 representation by default",
+			accept: "application/json",
+			expectedBook: map[string]interface{}{
+				"Id":        1.0,
+				"Title":     "title 1",
+				"Author":    "author 1",
+				"CreatedAt": "2023-03-23T19:00:00.62337Z",
+			},
+			expectedCar: map[string]interface{}{
+				"Id":        1.0,
+				"Brand":     "brand 1",
+				"Model":     "model 1",
+				"CreatedAt": "2023-03-23T19:00:00.62337Z",
+			},
+		},
+		{
+			name:   "returns v2 representation when requested by media type",
+			accept: "application/vnd.pagination.v2+json; q=1.0, application/json",
+			expectedBook: map[string]interface{}{
+				"id":         1.0,
+				"title":      "title 1",
+				"author":     "author 1",
+				"created_at": "2023-03-23T19:00:00.62337Z",
+			},
+			expectedCar: map[string]interface{}{
+				"id":         1.0,
+				"brand":      "brand 1",
+				"model":      "model 1",
+				"created_at": "2023-03-23T19:00:00.62337Z",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := api.NewServer(internal.BookRepositoryMockReturnBooks(10, 0, t), internal.CarRepositoryMockReturnCars(10, 1, t))
+
+			for _, target := range []struct {
+				url      string
+				handler  http.HandlerFunc
+				expected map[string]interface{}
+			}{
+				{"/books?limit=10&offset=0", srv.FetchAllBooks, tc.expectedBook},
+				{"/cars?cursor=1&limit=10", srv.FetchAllCars, tc.expectedCar},
+			} {
+				req, err := http.NewRequest("GET", target.url, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("Accept", tc.accept)
+
+				rr := httptest.NewRecorder()
+				target.handler.ServeHTTP(rr, req)
+
+				var actual api.PaginatedResponse[map[string]interface{}]
+				if err := json.NewDecoder(rr.Body).Decode(&actual); err != nil {
+					t.Fatalf("unexpected error while parsing response body: %v", err)
+				}
+
+				if !reflect.DeepEqual(actual.Data[0], target.expected) {
+					t.Errorf("api returned unexpected representation for %s: got %v want %v", target.url, actual.Data[0], target.expected)
+				}
+			}
+		})
+	}
+}
+
 func buildBooksParameters(limit, offset interface{}) string {
 	return fmt.Sprintf("?limit=%d&offset=%d", limit, offset)
 }
@@ -294,3 +367,27 @@ func buildBooksParameters(limit, offset interface{}) string {
 func buildCarsParameters(cursor, limit interface{}) string {
 	return fmt.Sprintf("?cursor=%d&limit=%d", cursor, limit)
 }
+
+func TestMaxLimit(t *testing.T) {
+	testCases := []struct {
+		url            string
+		opts           []api.Option
+		expectedStatus int
+	}{
+		{url: "/v2/books?limit=1000&offset=0", expectedStatus: http.StatusOK},
+		{url: "/v2/books?limit=10000000&offset=0", expectedStatus: http.StatusBadRequest},
+		{url: "/v2/cars?cursor=1&limit=10000000", expectedStatus: http.StatusBadRequest},
+		{url: "/v2/cars?cursor=1&limit=20", opts: []api.Option{api.WithMaxLimit(10)}, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			srv := api.NewServer(internal.BookRepositoryMockReturnBooks(1000, 0, t), internal.CarRepositoryMockReturnCars(10, 1, t), tc.opts...)
+			rr := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", tc.url, nil))
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("api returned wrong status code: got %v want %v", rr.Code, tc.expectedStatus)
+			}
+		})
+	}
+}
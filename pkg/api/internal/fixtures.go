@@ -1,32 +1,62 @@
 package internal
 
 import (
+	"github.com/krukkrz/pagination/pkg/api"
 	books "github.com/krukkrz/pagination/pkg/books/model"
 	cars "github.com/krukkrz/pagination/pkg/cars/model"
+	"time"
 )
 
+var createdAt = time.Date(2023, 3, 23, 19, 0, 0, 623370000, time.UTC)
+
 var Books = []books.Book{
-	{1, "title 1", "author 1", "2023-03-23 19:00:00.62337"},
-	{2, "title 2", "author 2", "2023-03-23 19:00:00.62337"},
-	{3, "title 3", "author 3", "2023-03-23 19:00:00.62337"},
-	{4, "title 4", "author 4", "2023-03-23 19:00:00.62337"},
-	{5, "title 5", "author 5", "2023-03-23 19:00:00.62337"},
-	{6, "title 6", "author 6", "2023-03-23 19:00:00.62337"},
-	{7, "title 7", "author 7", "2023-03-23 19:00:00.62337"},
-	{8, "title 8", "author 8", "2023-03-23 19:00:00.62337"},
-	{9, "title 9", "author 9", "2023-03-23 19:00:00.62337"},
-	{10, "title 10", "author 10", "2023-03-23 19:00:00.62337"},
+	{Id: 1, Title: "title 1", Author: "author 1", CreatedAt: createdAt},
+	{Id: 2, Title: "title 2", Author: "author 2", CreatedAt: createdAt},
+	{Id: 3, Title: "title 3", Author: "author 3", CreatedAt: createdAt},
+	{Id: 4, Title: "title 4", Author: "author 4", CreatedAt: createdAt},
+	{Id: 5, Title: "title 5", Author: "author 5", CreatedAt: createdAt},
+	{Id: 6, Title: "title 6", Author: "author 6", CreatedAt: createdAt},
+	{Id: 7, Title: "title 7", Author: "author 7", CreatedAt: createdAt},
+	{Id: 8, Title: "title 8", Author: "author 8", CreatedAt: createdAt},
+	{Id: 9, Title: "title 9", Author: "author 9", CreatedAt: createdAt},
+	{Id: 10, Title: "title 10", Author: "author 10", CreatedAt: createdAt},
+}
+
+var BooksV1 = []api.BookV1{
+	{Id: 1, Title: "title 1", Author: "author 1", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 2, Title: "title 2", Author: "author 2", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 3, Title: "title 3", Author: "author 3", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 4, Title: "title 4", Author: "author 4", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 5, Title: "title 5", Author: "author 5", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 6, Title: "title 6", Author: "author 6", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 7, Title: "title 7", Author: "author 7", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 8, Title: "title 8", Author: "author 8", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 9, Title: "title 9", Author: "author 9", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 10, Title: "title 10", Author: "author 10", CreatedAt: "2023-03-23T19:00:00.62337Z"},
 }
 
 var Cars = []cars.Car{
-	{1, "brand 1", "model 1", "2023-03-23 19:00:00.62337"},
-	{2, "brand 2", "model 2", "2023-03-23 19:00:00.62337"},
-	{3, "brand 3", "model 3", "2023-03-23 19:00:00.62337"},
-	{4, "brand 4", "model 4", "2023-03-23 19:00:00.62337"},
-	{5, "brand 5", "model 5", "2023-03-23 19:00:00.62337"},
-	{6, "brand 6", "model 6", "2023-03-23 19:00:00.62337"},
-	{7, "brand 7", "model 7", "2023-03-23 19:00:00.62337"},
-	{8, "brand 8", "model 8", "2023-03-23 19:00:00.62337"},
-	{9, "brand 9", "model 9", "2023-03-23 19:00:00.62337"},
-	{10, "brand 10", "model 10", "2023-03-23 19:00:00.62337"},
+	{Id: 1, Brand: "brand 1", Model: "model 1", CreatedAt: createdAt},
+	{Id: 2, Brand: "brand 2", Model: "model 2", CreatedAt: createdAt},
+	{Id: 3, Brand: "brand 3", Model: "model 3", CreatedAt: createdAt},
+	{Id: 4, Brand: "brand 4", Model: "model 4", CreatedAt: createdAt},
+	{Id: 5, Brand: "brand 5", Model: "model 5", CreatedAt: createdAt},
+	{Id: 6, Brand: "brand 6", Model: "model 6", CreatedAt: createdAt},
+	{Id: 7, Brand: "brand 7", Model: "model 7", CreatedAt: createdAt},
+	{Id: 8, Brand: "brand 8", Model: "model 8", CreatedAt: createdAt},
+	{Id: 9, Brand: "brand 9", Model: "model 9", CreatedAt: createdAt},
+	{Id: 10, Brand: "brand 10", Model: "model 10", CreatedAt: createdAt},
+}
+
+var CarsV1 = []api.CarV1{
+	{Id: 1, Brand: "brand 1", Model: "model 1", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 2, Brand: "brand 2", Model: "model 2", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 3, Brand: "brand 3", Model: "model 3", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 4, Brand: "brand 4", Model: "model 4", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 5, Brand: "brand 5", Model: "model 5", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 6, Brand: "brand 6", Model: "model 6", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 7, Brand: "brand 7", Model: "model 7", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 8, Brand: "brand 8", Model: "model 8", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 9, Brand: "brand 9", Model: "model 9", CreatedAt: "2023-03-23T19:00:00.62337Z"},
+	{Id: 10, Brand: "brand 10", Model: "model 10", CreatedAt: "2023-03-23T19:00:00.62337Z"},
 }
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"context"
 	"fmt"
 	"github.com/krukkrz/pagination/pkg/api"
 	books "github.com/krukkrz/pagination/pkg/books/model"
@@ -15,7 +16,7 @@ type BookRepositorySuccessMock struct {
 	t              *testing.T
 }
 
-func (b BookRepositorySuccessMock) FetchAll(limit, offset int) ([]books.Book, error) {
+func (b BookRepositorySuccessMock) FetchAll(_ context.Context, limit, offset int) ([]books.Book, error) {
 	if b.expectedOffset != offset {
 		b.t.Fatalf("incorrect offset expecting: %d, got: %d", b.expectedOffset, offset)
 	}
@@ -36,7 +37,7 @@ func BookRepositoryMockReturnBooks(expectedLimit, expectedOffset int, t *testing
 
 type BookRepositoryErrorMock struct{}
 
-func (b BookRepositoryErrorMock) FetchAll(limit, offset int) ([]books.Book, error) {
+func (b BookRepositoryErrorMock) FetchAll(_ context.Context, limit, offset int) ([]books.Book, error) {
 	return nil, fmt.Errorf("mocked error")
 }
 
@@ -50,7 +51,7 @@ type CarRepositorySuccessMock struct {
 	t              *testing.T
 }
 
-func (b CarRepositorySuccessMock) FetchAll(cursor, limit int) ([]cars.Car, error) {
+func (b CarRepositorySuccessMock) FetchAll(_ context.Context, cursor, limit int) ([]cars.Car, error) {
 	if b.expectedCursor != cursor {
 		log.Printf("incorrect cursor expecting: %d, got: %d", b.expectedCursor, cursor)
 		b.t.Fail()
@@ -73,7 +74,7 @@ func CarRepositoryMockReturnCars(expectedLimit, expectedCursor int, t *testing.T
 
 type CarRepositoryErrorMock struct{}
 
-func (b CarRepositoryErrorMock) FetchAll(cursor, limit int) ([]cars.Car, error) {
+func (b CarRepositoryErrorMock) FetchAll(_ context.Context, cursor, limit int) ([]cars.Car, error) {
 	return nil, fmt.Errorf("mocked error")
 }
 
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/auth"
+	"github.com/krukkrz/pagination/pkg/cors"
+	"github.com/krukkrz/pagination/pkg/ratelimit"
+	"log"
+	"net/http"
+	"time"
+)
+
+var (
+	defaultDeprecation = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	defaultSunset      = time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC)
+)
+
+// WithDeprecation sets the dates announced on unversioned routes through the
+// Deprecation and Sunset headers.
+func WithDeprecation(deprecation, sunset time.Time) Option {
+	return func(s *Server) {
+		s.deprecation = deprecation
+		s.sunset = sunset
+	}
+}
+
+// WithRateLimiter limits requests of every client, separately for books and
+// cars.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(s *Server) {
+		s.rateLimiter = limiter
+	}
+}
+
+// WithAuthentication requires every request to carry an API key from the
+// store with the read scope of the requested resource.
+func WithAuthentication(store *auth.Store) Option {
+	return func(s *Server) {
+		s.keyStore = store
+	}
+}
+
+// WithCORS lets browsers call the API from other origins.
+func WithCORS(config cors.Config) Option {
+	return func(s *Server) {
+		s.cors = &config
+	}
+}
+
+func (s Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/v1/books", s.route("books", pinVersion(V1, s.fetchAllBooks)))
+	mux.Handle("/v1/cars", s.route("cars", pinVersion(V1, s.fetchAllCars)))
+	mux.Handle("/v2/books", s.route("books", pinVersion(V2, s.fetchAllBooks)))
+	mux.Handle("/v2/cars", s.route("cars", pinVersion(V2, s.fetchAllCars)))
+	mux.Handle("/books", s.route("books", s.deprecated("/v1/books", s.FetchAllBooks)))
+	mux.Handle("/cars", s.route("cars", s.deprecated("/v1/cars", s.FetchAllCars)))
+	if s.bookExporter != nil {
+		mux.Handle("/books/export", s.route("books", s.ExportBooks))
+	}
+	if s.carExporter != nil {
+		mux.Handle("/cars/export", s.route("cars", s.ExportCars))
+	}
+	if s.carScans != nil {
+		mux.Handle("/cars/scans", s.route("cars", s.CarScans))
+		mux.Handle("/cars/scans/", s.route("cars", s.CarScan))
+	}
+	if s.bookChanges != nil {
+		mux.Handle("/books/changes", s.route("books", s.BookChanges))
+	}
+	if s.carChanges != nil {
+		mux.Handle("/cars/changes", s.route("cars", s.CarChanges))
+	}
+	return mux
+}
+
+// route wraps the handler of a resource with the middlewares configured on the
+// server.
+func (s Server) route(resource string, handler http.HandlerFunc) http.Handler {
+	var h http.Handler = handler
+	if s.keyStore != nil {
+		h = auth.Middleware(s.keyStore, resource+":read", h)
+	}
+	// requests with unknown keys are rate limited by address before they are
+	// rejected, so guessing keys is limited as well
+	if s.rateLimiter != nil {
+		h = s.rateLimiter.Middleware(resource, h)
+	}
+	if s.keyStore != nil {
+		h = auth.Identify(s.keyStore, h)
+	}
+	// preflight requests carry no credentials, so CORS goes before authentication
+	if s.cors != nil {
+		h = cors.Middleware(*s.cors, h)
+	}
+	return h
+}
+
+func pinVersion(version Version, handler func(http.ResponseWriter, *http.Request, Version)) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		handler(rw, r, version)
+	}
+}
+
+func (s Server) deprecated(successor string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		log.Printf("deprecated route %s called by %s (%s)", r.URL.Path, r.RemoteAddr, r.UserAgent())
+		rw.Header().Set("Deprecation", fmt.Sprintf("@%d", s.deprecation.Unix()))
+		rw.Header().Set("Sunset", s.sunset.UTC().Format(http.TimeFormat))
+		rw.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+		handler(rw, r)
+	}
+}
@@ -0,0 +1,233 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/api/internal"
+	"github.com/krukkrz/pagination/pkg/auth"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/contract"
+	"github.com/krukkrz/pagination/pkg/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVersionedRoutes(t *testing.T) {
+	deprecation := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name               string
+		url                string
+		expectedDeprecated bool
+		expectedSuccessor  string
+		expectedKey        string
+	}{
+		{
+			name:        "v1 books keep the original representation",
+			url:         "/v1/books?limit=10&offset=0",
+			expectedKey: "Id",
+		},
+		{
+			name:        "v1 cars keep the original representation",
+			url:         "/v1/cars?cursor=1&limit=10",
+			expectedKey: "Id",
+		},
+		{
+			name:        "v2 books use the new representation",
+			url:         "/v2/books?limit=10&offset=0",
+			expectedKey: "id",
+		},
+		{
+			name:        "v2 cars use the new representation",
+			url:         "/v2/cars?cursor=1&limit=10",
+			expectedKey: "id",
+		},
+		{
+			name:               "unversioned books are a deprecated alias of v1",
+			url:                "/books?limit=10&offset=0",
+			expectedKey:        "Id",
+			expectedDeprecated: true,
+			expectedSuccessor:  `</v1/books>; rel="successor-version"`,
+		},
+		{
+			name:               "unversioned cars are a deprecated alias of v1",
+			url:                "/cars?cursor=1&limit=10",
+			expectedKey:        "Id",
+			expectedDeprecated: true,
+			expectedSuccessor:  `</v1/cars>; rel="successor-version"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := api.NewServer(
+				internal.BookRepositoryMockReturnBooks(10, 0, t),
+				internal.CarRepositoryMockReturnCars(10, 1, t),
+				api.WithDeprecation(deprecation, sunset),
+			)
+
+			req := httptest.NewRequest("GET", tc.url, nil)
+			rr := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("api returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+
+			var actual api.PaginatedResponse[map[string]interface{}]
+			if err := json.NewDecoder(rr.Body).Decode(&actual); err != nil {
+				t.Fatalf("unexpected error while parsing response body: %v", err)
+			}
+			if _, ok := actual.Data[0][tc.expectedKey]; !ok {
+				t.Errorf("expecting key %s in returned element, got: %v", tc.expectedKey, actual.Data[0])
+			}
+
+			if !tc.expectedDeprecated {
+				if header := rr.Header().Get("Deprecation"); header != "" {
+					t.Errorf("unexpected Deprecation header: %s", header)
+				}
+				return
+			}
+			if header := rr.Header().Get("Deprecation"); header != "@1790812800" {
+				t.Errorf("unexpected Deprecation header, got: %s, expected: %s", header, "@1790812800")
+			}
+			if header := rr.Header().Get("Sunset"); header != "Thu, 01 Apr 2027 00:00:00 GMT" {
+				t.Errorf("unexpected Sunset header, got: %s, expected: %s", header, "Thu, 01 Apr 2027 00:00:00 GMT")
+			}
+			if header := rr.Header().Get("Link"); header != tc.expectedSuccessor {
+				t.Errorf("unexpected Link header, got: %s, expected: %s", header, tc.expectedSuccessor)
+			}
+		})
+	}
+}
+
+func TestV2Envelope(t *testing.T) {
+	srv := api.NewServer(internal.BookRepositoryMockReturnBooks(10, 10, t), internal.CarRepositoryMockReturnCars(5, 1, t))
+
+	t.Run("books page links and metadata", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/v2/books?limit=10&offset=10", nil))
+
+		var actual contract.PageResponse[booksModels.Book]
+		if err := json.NewDecoder(rr.Body).Decode(&actual); err != nil {
+			t.Fatalf("unexpected error while parsing response body: %v", err)
+		}
+
+		if !reflect.DeepEqual(actual.Data, internal.Books) {
+			t.Errorf("api returned unexpected body: got %v want %v", actual.Data, internal.Books)
+		}
+		assertLink(t, "self", &actual.Links.Self, "/v2/books?limit=10&offset=10")
+		assertLink(t, "first", &actual.Links.First, "/v2/books?limit=10&offset=0")
+		assertLink(t, "prev", actual.Links.Prev, "/v2/books?limit=10&offset=0")
+		assertLink(t, "next", actual.Links.Next, "/v2/books?limit=10&offset=20")
+		if actual.Meta.Limit != 10 || actual.Meta.Offset == nil || *actual.Meta.Offset != 10 || actual.Meta.Count != 10 {
+			t.Errorf("unexpected meta: %+v", actual.Meta)
+		}
+	})
+
+	t.Run("cars page follows the last returned id and stops on a short page", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/v2/cars?cursor=1&limit=5", nil))
+
+		var actual contract.PageResponse[carsModels.Car]
+		if err := json.NewDecoder(rr.Body).Decode(&actual); err != nil {
+			t.Fatalf("unexpected error while parsing response body: %v", err)
+		}
+
+		assertLink(t, "self", &actual.Links.Self, "/v2/cars?cursor=1&limit=5")
+		assertLink(t, "first", &actual.Links.First, "/v2/cars?cursor=1&limit=5")
+		assertLink(t, "prev", actual.Links.Prev, "")
+		assertLink(t, "next", actual.Links.Next, "/v2/cars?cursor=11&limit=5")
+		if actual.Meta.Cursor == nil || *actual.Meta.Cursor != 1 || actual.Meta.Offset != nil {
+			t.Errorf("unexpected meta: %+v", actual.Meta)
+		}
+	})
+}
+
+func assertLink(t *testing.T, name string, actual *string, expected string) {
+	t.Helper()
+	if expected == "" {
+		if actual != nil {
+			t.Errorf("expecting no %s link, got: %s", name, *actual)
+		}
+		return
+	}
+	if actual == nil || *actual != expected {
+		t.Errorf("unexpected %s link value, got: %v, expected: %s", name, actual, expected)
+	}
+}
+
+func TestAuthenticatedRoutes(t *testing.T) {
+	store := auth.NewStore()
+	store.Add(auth.Hash("books-only"), auth.Key{Id: "books-only", Scopes: []string{auth.BooksRead}})
+	srv := api.NewServer(
+		internal.BookRepositoryMockReturnBooks(10, 0, t),
+		internal.CarRepositoryMockReturnCars(10, 1, t),
+		api.WithAuthentication(store),
+	)
+
+	testCases := []struct {
+		name           string
+		url            string
+		expectedStatus int
+	}{
+		{name: "books require books:read", url: "/v2/books?limit=10&offset=0", expectedStatus: http.StatusOK},
+		{name: "cars require cars:read", url: "/v2/cars?cursor=1&limit=10", expectedStatus: http.StatusForbidden},
+		{name: "unversioned routes are protected as well", url: "/cars?cursor=1&limit=10", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.url, nil)
+			req.Header.Set("Authorization", "Bearer books-only")
+			rr := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("api returned wrong status code: got %v want %v", rr.Code, tc.expectedStatus)
+			}
+			if tc.expectedStatus == http.StatusOK && rr.Header().Get("Cache-Control") != "private, max-age=5" {
+				t.Errorf("expecting authenticated pages to be cached privately, got: %s", rr.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestRateLimitedAuthenticatedRoutes(t *testing.T) {
+	store := auth.NewStore()
+	store.Add(auth.Hash("books-only"), auth.Key{Id: "books-only", Scopes: []string{auth.BooksRead}})
+	srv := api.NewServer(
+		internal.BookRepositoryMockReturnBooks(10, 0, t),
+		internal.CarRepositoryMockReturnError(),
+		api.WithAuthentication(store),
+		api.WithRateLimiter(ratelimit.New(ratelimit.Config{Default: ratelimit.Policy{
+			Requests: ratelimit.Limit{Rate: 0.01, Burst: 2},
+			Cost:     ratelimit.Limit{Rate: 100, Burst: 100},
+		}})),
+	)
+
+	get := func(secret string) int {
+		req := httptest.NewRequest("GET", "/v2/books?limit=10&offset=0", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("Authorization", "Bearer "+secret)
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	// every guessed key comes from the same address and shares its bucket
+	for i, expected := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		if actual := get(fmt.Sprintf("guess-%d", i)); actual != expected {
+			t.Errorf("guess %d returned wrong status code: got %v want %v", i, actual, expected)
+		}
+	}
+	if actual := get("books-only"); actual != http.StatusOK {
+		t.Errorf("expecting a known key to have a bucket of its own, got: %v", actual)
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/problem"
+	"github.com/krukkrz/pagination/pkg/ratelimit"
+	"github.com/krukkrz/pagination/pkg/scan"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultScanFetch = 500
+	maxScanFetch     = 5000
+)
+
+type CarScanner interface {
+	Scan(ctx context.Context) (scan.Cursor[carsModels.Car], error)
+}
+
+type ScanResponse struct {
+	Id        string    `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Next      string    `json:"next"`
+}
+
+type ScanPage[T any] struct {
+	Data []T `json:"data"`
+	// Next is null once the scan returned its last row and was closed.
+	Next      *string    `json:"next"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// WithScans lets clients keep their position in a scan of every car on the
+// database side, through /cars/scans.
+func WithScans(cars CarScanner, config scan.Config) Option {
+	return func(s *Server) {
+		s.carScans = scan.NewManager(cars.Scan, config)
+	}
+}
+
+// CarScans opens a scan on POST /cars/scans.
+func (s Server) CarScans(rw http.ResponseWriter, r *http.Request) {
+	log.Printf("received a scan request: %s %s", r.Method, r.RequestURI)
+	if r.Method != "POST" {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	info, err := s.carScans.Open(r.Context(), ratelimit.ClientKey(r))
+	switch {
+	case errors.Is(err, scan.ErrTooManyScans):
+		problem.Write(rw, http.StatusTooManyRequests, "too many open scans, close one or let it expire")
+		return
+	case err != nil:
+		log.Printf("error while opening scan: %v", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	next := scanLink(info.Id, defaultScanFetch)
+	rw.Header().Set("Location", next)
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(ScanResponse{Id: info.Id, ExpiresAt: info.ExpiresAt, Next: next})
+}
+
+// CarScan fetches the next rows of a scan on GET /cars/scans/{id}?n=500, and
+// closes it on DELETE.
+func (s Server) CarScan(rw http.ResponseWriter, r *http.Request) {
+	log.Printf("received a scan request: %s %s", r.Method, r.RequestURI)
+	id := strings.TrimPrefix(r.URL.Path, "/cars/scans/")
+	client := ratelimit.ClientKey(r)
+
+	switch r.Method {
+	case "GET":
+	case "DELETE":
+		if err := s.carScans.Close(id, client); errors.Is(err, scan.ErrNotFound) {
+			problem.Write(rw, http.StatusNotFound, "scan not found, it may have expired")
+			return
+		} else if err != nil {
+			log.Printf("error while closing scan: %v", err)
+		}
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	n := defaultScanFetch
+	if param := r.URL.Query().Get("n"); param != "" {
+		var err error
+		if n, err = strconv.Atoi(param); err != nil || n < 1 || n > maxScanFetch {
+			problem.Write(rw, http.StatusBadRequest, fmt.Sprintf("n must be between 1 and %d", maxScanFetch))
+			return
+		}
+	}
+
+	cars, info, done, err := s.carScans.Fetch(r.Context(), id, client, n)
+	switch {
+	case errors.Is(err, scan.ErrNotFound):
+		problem.Write(rw, http.StatusNotFound, "scan not found, it may have expired")
+		return
+	case err != nil:
+		log.Printf("error while fetching scan %s: %v", id, err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	page := ScanPage[carsModels.Car]{Data: nonNil(cars)}
+	if !done {
+		next := scanLink(id, n)
+		page.Next = &next
+		page.ExpiresAt = &info.ExpiresAt
+	}
+	// every fetch moves the scan, so its pages are never cached
+	rw.Header().Set("Cache-Control", "no-store")
+	s.encodeJsonResponse(rw, r, page)
+}
+
+func scanLink(id string, n int) string {
+	return fmt.Sprintf("/cars/scans/%s?n=%d", id, n)
+}
@@ -0,0 +1,118 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/api/internal"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/scan"
+	"github.com/krukkrz/pagination/pkg/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScans(t *testing.T) {
+	cars := memory.NewCarRepository()
+	for i := 1; i <= 25; i++ {
+		cars.Insert(carsModels.Car{Id: i, Brand: fmt.Sprintf("brand %d", i), Model: fmt.Sprintf("model %d", i), CreatedAt: time.Now()})
+	}
+	srv := api.NewServer(
+		internal.BookRepositoryMockReturnBooks(10, 0, t),
+		cars,
+		api.WithScans(cars, scan.Config{TTL: time.Minute, MaxPerClient: 1, MaxOpen: 10}),
+	)
+	serve := func(method, url string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, httptest.NewRequest(method, url, nil))
+		return rr
+	}
+
+	rr := serve("POST", "/cars/scans")
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("api returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+	}
+	var opened api.ScanResponse
+	if err := json.NewDecoder(rr.Body).Decode(&opened); err != nil {
+		t.Fatalf("unexpected error while parsing response body: %v", err)
+	}
+
+	if rr := serve("POST", "/cars/scans"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expecting a second scan of the client to be refused, got: %v", rr.Code)
+	}
+
+	next, count := fmt.Sprintf("/cars/scans/%s?n=10", opened.Id), 0
+	for next != "" {
+		rr := serve("GET", next)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("api returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var page api.ScanPage[carsModels.Car]
+		if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+			t.Fatalf("unexpected error while parsing response body: %v", err)
+		}
+		for _, car := range page.Data {
+			count++
+			if car.Id != count {
+				t.Fatalf("expecting car %d next, got: %d", count, car.Id)
+			}
+		}
+		next = ""
+		if page.Next != nil {
+			next = *page.Next
+		}
+	}
+	if count != 25 {
+		t.Errorf("expecting 25 cars, got: %d", count)
+	}
+
+	if rr := serve("GET", fmt.Sprintf("/cars/scans/%s?n=10", opened.Id)); rr.Code != http.StatusNotFound {
+		t.Errorf("expecting finished scan to be gone, got: %v", rr.Code)
+	}
+
+	t.Run("scans can be closed early", func(t *testing.T) {
+		rr := serve("POST", "/cars/scans")
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expecting the finished scan to free its slot, got: %v", rr.Code)
+		}
+		if rr := serve("DELETE", rr.Header().Get("Location")); rr.Code != http.StatusNoContent {
+			t.Errorf("api returned wrong status code: got %v want %v", rr.Code, http.StatusNoContent)
+		}
+	})
+
+	t.Run("fetch size is bounded", func(t *testing.T) {
+		if rr := serve("GET", "/cars/scans/unknown?n=100000"); rr.Code != http.StatusBadRequest {
+			t.Errorf("api returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("made up credentials do not lift the client limit", func(t *testing.T) {
+		rr := serve("POST", "/cars/scans")
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("api returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+		defer serve("DELETE", rr.Header().Get("Location"))
+		for i := 0; i < 3; i++ {
+			req := httptest.NewRequest("POST", "/cars/scans", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer random-%d", i))
+			rr := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rr, req)
+			if rr.Code != http.StatusTooManyRequests {
+				t.Errorf("expecting scan %d to be refused, got: %v", i, rr.Code)
+			}
+		}
+	})
+
+	t.Run("closing the server closes its scans", func(t *testing.T) {
+		rr := serve("POST", "/cars/scans")
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("api returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+		srv.Close()
+		if rr := serve("GET", rr.Header().Get("Location")); rr.Code != http.StatusNotFound {
+			t.Errorf("expecting the scan to be closed, got: %v", rr.Code)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	"github.com/krukkrz/pagination/pkg/contract"
+	"github.com/krukkrz/pagination/pkg/problem"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// defaultSnapshotTTL is how long the pages of a snapshot can be requested
+// after its first page.
+const defaultSnapshotTTL = 15 * time.Minute
+
+// SnapshotBookRepository pages through the books created until a point in
+// time. It hides the books inserted after it, but not deletes or updates.
+type SnapshotBookRepository interface {
+	FetchAllAsOf(ctx context.Context, asOf time.Time, limit, offset int) ([]booksModels.Book, error)
+}
+
+// WithSnapshots keeps pages from being shifted by books inserted while a
+// client pages through them: the first page asked for with ?consistent=true
+// opens a snapshot, and the links of its pages keep it for ttl. A snapshot
+// only hides inserts, deleted books still shift its pages and updated books
+// are served as they are now.
+func WithSnapshots(repository SnapshotBookRepository, ttl time.Duration) Option {
+	return func(s *Server) {
+		s.snapshots = repository
+		s.snapshotTTL = ttl
+		if s.snapshotTTL <= 0 {
+			s.snapshotTTL = defaultSnapshotTTL
+		}
+	}
+}
+
+type snapshot struct {
+	asOf    time.Time
+	expires time.Time
+}
+
+var (
+	errSnapshotInvalid = errors.New("invalid snapshot")
+	errSnapshotExpired = errors.New("snapshot expired")
+)
+
+// openSnapshot returns the snapshot asked for by the request, or nil when the
+// request is not consistent. Problems are written to the response.
+func (s Server) openSnapshot(rw http.ResponseWriter, r *http.Request) (*snapshot, bool) {
+	query := r.URL.Query()
+	token := query.Get("snapshot")
+	if token == "" && query.Get("consistent") != "true" {
+		return nil, true
+	}
+	if s.snapshots == nil {
+		problem.Write(rw, http.StatusBadRequest, "consistent pagination is not enabled")
+		return nil, false
+	}
+
+	now := time.Now()
+	if token == "" {
+		// postgres keeps microseconds, anything finer would hide rows created at asOf
+		asOf := now.UTC().Truncate(time.Microsecond)
+		return &snapshot{asOf: asOf, expires: asOf.Add(s.snapshotTTL)}, true
+	}
+
+	snap, err := s.decodeSnapshot(token, now)
+	switch {
+	case errors.Is(err, errSnapshotExpired):
+		problem.Write(rw, http.StatusGone, "snapshot expired, start again with consistent=true")
+		return nil, false
+	case err != nil:
+		problem.Write(rw, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return snap, true
+}
+
+func (s Server) decodeSnapshot(token string, now time.Time) (*snapshot, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errSnapshotInvalid
+	}
+	asOf, err := time.Parse(time.RFC3339Nano, string(raw))
+	if err != nil || asOf.After(now) {
+		return nil, errSnapshotInvalid
+	}
+	snap := &snapshot{asOf: asOf, expires: asOf.Add(s.snapshotTTL)}
+	if now.After(snap.expires) {
+		return nil, errSnapshotExpired
+	}
+	return snap, nil
+}
+
+func (snap *snapshot) token() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(snap.asOf.Format(time.RFC3339Nano)))
+}
+
+// params are appended to the links of the pages of the snapshot.
+func (snap *snapshot) params() string {
+	if snap == nil {
+		return ""
+	}
+	return "&snapshot=" + url.QueryEscape(snap.token())
+}
+
+func (snap *snapshot) meta() *contract.SnapshotMeta {
+	if snap == nil {
+		return nil
+	}
+	return &contract.SnapshotMeta{Token: snap.token(), AsOf: snap.asOf, ExpiresAt: snap.expires}
+}
@@ -0,0 +1,108 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/api/internal"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	"github.com/krukkrz/pagination/pkg/contract"
+	"github.com/krukkrz/pagination/pkg/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSnapshots(t *testing.T) {
+	createdAt := time.Date(2023, 3, 23, 19, 0, 0, 0, time.UTC)
+	books := memory.NewBookRepository()
+	for i := 2; i <= 21; i++ {
+		books.Insert(booksModels.Book{Id: i, Title: fmt.Sprintf("title %d", i), Author: fmt.Sprintf("author %d", i), CreatedAt: createdAt})
+	}
+	srv := api.NewServer(books, internal.CarRepositoryMockReturnCars(10, 1, t), api.WithSnapshots(books, time.Minute))
+
+	get := func(url string) (*httptest.ResponseRecorder, contract.PageResponse[booksModels.Book]) {
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+		var page contract.PageResponse[booksModels.Book]
+		if rr.Code == http.StatusOK {
+			if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+				t.Fatalf("unexpected error while parsing response body: %v", err)
+			}
+		}
+		return rr, page
+	}
+
+	t.Run("pages of a snapshot ignore books created after it", func(t *testing.T) {
+		rr, first := get("/v2/books?limit=10&offset=0&consistent=true")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("api returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if first.Meta.Snapshot == nil || first.Links.Next == nil {
+			t.Fatalf("expecting snapshot metadata and a next link, got: %+v, %+v", first.Meta, first.Links)
+		}
+		if expires, err := http.ParseTime(rr.Header().Get("Snapshot-Expires")); err != nil || expires.Unix() != first.Meta.Snapshot.ExpiresAt.Unix() {
+			t.Errorf("unexpected Snapshot-Expires header: %s", rr.Header().Get("Snapshot-Expires"))
+		}
+
+		books.Insert(booksModels.Book{Id: 1, Title: "title 1", Author: "author 1", CreatedAt: time.Now()})
+
+		_, second := get(*first.Links.Next)
+		if last, next := first.Data[len(first.Data)-1].Id, second.Data[0].Id; next != last+1 {
+			t.Errorf("expecting page of the snapshot to continue after book %d, got: %d", last, next)
+		}
+		if second.Meta.Snapshot == nil || second.Meta.Snapshot.Token != first.Meta.Snapshot.Token {
+			t.Errorf("expecting the snapshot to be kept, got: %+v", second.Meta.Snapshot)
+		}
+
+		_, latest := get("/v2/books?limit=10&offset=0")
+		if latest.Data[0].Id != 1 {
+			t.Errorf("expecting pages without snapshot to see the new book, got: %d", latest.Data[0].Id)
+		}
+	})
+
+	t.Run("pages of a snapshot still see deletes and updates", func(t *testing.T) {
+		_, first := get("/v2/books?limit=10&offset=0&consistent=true")
+		if first.Links.Next == nil {
+			t.Fatalf("expecting a next link, got: %+v", first.Links)
+		}
+		last := first.Data[len(first.Data)-1]
+
+		books.Delete(first.Data[0].Id)
+		updated := booksModels.Book{Id: last.Id + 2, Title: "new title", Author: "author", CreatedAt: createdAt}
+		books.Update(updated)
+
+		_, second := get(*first.Links.Next)
+		if second.Data[0].Id != last.Id+2 {
+			t.Errorf("expecting the delete to shift the page past book %d, got: %d", last.Id+1, second.Data[0].Id)
+		}
+		if second.Data[0].Title != updated.Title {
+			t.Errorf("expecting the updated book, got: %+v", second.Data[0])
+		}
+	})
+
+	t.Run("expired snapshots are gone", func(t *testing.T) {
+		token := "MjAyMy0wMy0yM1QxOTowMDowMFo" // 2023-03-23T19:00:00Z
+		rr, _ := get("/v2/books?limit=10&offset=10&snapshot=" + token)
+		if rr.Code != http.StatusGone {
+			t.Errorf("api returned wrong status code: got %v want %v", rr.Code, http.StatusGone)
+		}
+	})
+
+	t.Run("malformed snapshots are rejected", func(t *testing.T) {
+		rr, _ := get("/v2/books?limit=10&offset=10&snapshot=nope")
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("api returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("consistent pages need snapshots to be enabled", func(t *testing.T) {
+		srv := api.NewServer(books, internal.CarRepositoryMockReturnCars(10, 1, t))
+		rr := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/v2/books?limit=10&offset=0&consistent=true", nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("api returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+}
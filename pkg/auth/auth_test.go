@@ -0,0 +1,144 @@
+package auth_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/krukkrz/pagination/pkg/auth"
+	"github.com/krukkrz/pagination/pkg/problem"
+	_ "github.com/proullon/ramsql/driver"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	content := "# dashboards\n" +
+		"dashboard " + auth.Hash("dashboard-secret") + " books:read,cars:read\n" +
+		"\n" +
+		"importer " + auth.Hash("importer-secret") + " books:write\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := auth.LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	key, ok := store.Lookup("dashboard-secret")
+	expected := auth.Key{Id: "dashboard", Scopes: []string{auth.BooksRead, auth.CarsRead}}
+	if !ok || !reflect.DeepEqual(key, expected) {
+		t.Errorf("unexpected key, got: %+v, expected: %+v", key, expected)
+	}
+	if _, ok := store.Lookup(auth.Hash("dashboard-secret")); ok {
+		t.Errorf("expecting the hash itself not to be accepted as a key")
+	}
+}
+
+func TestLoadTable(t *testing.T) {
+	db, err := sql.Open("ramsql", "Test API keys")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE api_keys (key_id VARCHAR ( 100 ) PRIMARY KEY, key_hash VARCHAR ( 64 ) NOT NULL, scopes VARCHAR ( 500 ) NOT NULL);`); err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+	if _, err := db.Exec("INSERT INTO api_keys (key_id, key_hash, scopes) VALUES ($1, $2, $3);", "crawler", auth.Hash("crawler-secret"), "cars:read"); err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+
+	store, err := auth.LoadTable(context.Background(), db)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	key, ok := store.Lookup("crawler-secret")
+	if !ok || key.Id != "crawler" || !key.HasScope(auth.CarsRead) {
+		t.Errorf("unexpected key: %+v", key)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	store := auth.NewStore()
+	store.Add(auth.Hash("reader"), auth.Key{Id: "reader", Scopes: []string{auth.BooksRead}})
+
+	var authenticated auth.Key
+	handler := auth.Middleware(store, auth.BooksRead, http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		authenticated, _ = auth.FromContext(r.Context())
+	}))
+
+	testCases := []struct {
+		name           string
+		authorization  string
+		scope          string
+		expectedStatus int
+	}{
+		{
+			name:           "rejects requests without a key",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "rejects other authorization schemes",
+			authorization:  "Basic cmVhZGVyOg==",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "rejects unknown keys",
+			authorization:  "Bearer writer",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "rejects keys without the scope",
+			authorization:  "Bearer reader",
+			scope:          auth.CarsRead,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "passes the key down to the handler",
+			authorization:  "Bearer reader",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := handler
+			if tc.scope != "" {
+				h = auth.Middleware(store, tc.scope, h)
+			}
+			req := httptest.NewRequest("GET", "/books?limit=10&offset=0", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("middleware returned wrong status code: got %v want %v", rr.Code, tc.expectedStatus)
+			}
+			if tc.expectedStatus == http.StatusOK {
+				if authenticated.Id != "reader" {
+					t.Errorf("expecting key in request context, got: %+v", authenticated)
+				}
+				return
+			}
+
+			if contentType := rr.Header().Get("Content-Type"); contentType != "application/problem+json" {
+				t.Errorf("unexpected Content-Type: %s", contentType)
+			}
+			var details problem.Details
+			if err := json.NewDecoder(rr.Body).Decode(&details); err != nil {
+				t.Fatalf("unexpected error while parsing response body: %v", err)
+			}
+			if details.Status != tc.expectedStatus {
+				t.Errorf("unexpected problem status, got: %d, expected: %d", details.Status, tc.expectedStatus)
+			}
+		})
+	}
+}
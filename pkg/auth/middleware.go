@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"github.com/krukkrz/pagination/pkg/problem"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// Identify passes the known API key of the request down in its context, for
+// middlewares running before Middleware such as rate limiting. Requests
+// without a known key are passed on as they are.
+func Identify(store *Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && secret != "" {
+			if key, ok := store.Lookup(secret); ok {
+				r = r.WithContext(NewContext(r.Context(), key))
+			}
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// Middleware lets through only requests with a known API key holding the
+// scope. The key is passed down in the request context.
+func Middleware(store *Store, scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || secret == "" {
+			rw.Header().Set("WWW-Authenticate", `Bearer realm="pagination"`)
+			problem.Write(rw, http.StatusUnauthorized, "missing bearer API key")
+			return
+		}
+
+		key, ok := FromContext(r.Context())
+		if !ok {
+			key, ok = store.Lookup(secret)
+		}
+		if !ok {
+			rw.Header().Set("WWW-Authenticate", `Bearer realm="pagination", error="invalid_token"`)
+			problem.Write(rw, http.StatusUnauthorized, "unknown API key")
+			return
+		}
+
+		log.Printf("request %s authenticated with key: %s", r.URL.Path, key.Id)
+		if !key.HasScope(scope) {
+			problem.Write(rw, http.StatusForbidden, "API key is missing scope "+scope)
+			return
+		}
+
+		next.ServeHTTP(rw, r.WithContext(NewContext(r.Context(), key)))
+	})
+}
+
+// NewContext returns a context carrying the API key of a request.
+func NewContext(ctx context.Context, key Key) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+func FromContext(ctx context.Context) (Key, bool) {
+	key, ok := ctx.Value(contextKey{}).(Key)
+	return key, ok
+}
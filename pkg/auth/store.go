@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	BooksRead  = "books:read"
+	BooksWrite = "books:write"
+	CarsRead   = "cars:read"
+	CarsWrite  = "cars:write"
+)
+
+type Key struct {
+	Id     string
+	Scopes []string
+}
+
+func (k Key) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Store holds API keys by the SHA-256 hash of their secret, so the secrets
+// themselves are never kept.
+type Store struct {
+	keys map[string]Key
+}
+
+func NewStore() *Store {
+	return &Store{keys: make(map[string]Key)}
+}
+
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Store) Add(hash string, key Key) {
+	s.keys[strings.ToLower(hash)] = key
+}
+
+func (s *Store) Lookup(secret string) (Key, bool) {
+	key, ok := s.keys[Hash(secret)]
+	return key, ok
+}
+
+// LoadFile reads keys from a file with one key per line:
+//
+//	<key id> <sha256 of the secret> <comma separated scopes>
+//
+// Empty lines and lines starting with # are skipped.
+func LoadFile(path string) (*Store, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening API keys file: %v", err)
+	}
+	defer f.Close()
+
+	store := NewStore()
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("malformed API key in line %d of %s", line, path)
+		}
+		store.Add(fields[1], Key{Id: fields[0], Scopes: splitScopes(fields[2])})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error while reading API keys file: %v", err)
+	}
+	return store, nil
+}
+
+// LoadTable reads keys from the api_keys table.
+func LoadTable(ctx context.Context, db *sql.DB) (*Store, error) {
+	query := "SELECT key_id, key_hash, scopes FROM api_keys;"
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error occured while running query: %s, error: %v", query, err)
+	}
+	defer rows.Close()
+
+	store := NewStore()
+	for rows.Next() {
+		var id, hash, scopes string
+		if err := rows.Scan(&id, &hash, &scopes); err != nil {
+			return nil, fmt.Errorf("error while parsing rows: %v", err)
+		}
+		store.Add(hash, Key{Id: id, Scopes: splitScopes(scopes)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating rows: %v", err)
+	}
+	return store, nil
+}
+
+func splitScopes(scopes string) []string {
+	var result []string
+	for _, scope := range strings.Split(scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			result = append(result, scope)
+		}
+	}
+	return result
+}
@@ -1,8 +1,10 @@
 package model
 
+import "time"
+
 type Book struct {
-	Id        int
-	Title     string
-	Author    string
-	CreatedAt string
+	Id        int       `json:"id" db:"book_id"`
+	Title     string    `json:"title" db:"title"`
+	Author    string    `json:"author" db:"author"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
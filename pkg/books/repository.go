@@ -1,40 +1,70 @@
 package books
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
 	"github.com/krukkrz/pagination/pkg/books/model"
+	"github.com/krukkrz/pagination/pkg/repository"
 	"log"
+	"time"
 )
 
+// DefaultDeferredJoinOffset is the offset from which books are read with a
+// deferred join.
+const DefaultDeferredJoinOffset = 1000
+
 type Repository struct {
-	db *sql.DB
+	*repository.Repository[model.Book]
+	deferredJoinOffset int
 }
 
-func NewRepository(db *sql.DB) *Repository {
-	return &Repository{
-		db: db,
+type Option func(*Repository)
+
+// WithDeferredJoin sets the offset from which pages skip the offset over ids
+// alone before reading the books of the page. Zero disables the deferred join.
+func WithDeferredJoin(offset int) Option {
+	return func(r *Repository) {
+		r.deferredJoinOffset = offset
 	}
 }
 
-func (r Repository) FetchAll(limit, offset int) ([]model.Book, error) {
-	log.Printf("fetching books with offset: %d and limit: %d", offset, limit)
-	query := "SELECT * FROM books LIMIT $1 OFFSET $2;"
-
-	rows, err := r.db.Query(query, limit, offset)
-	if err != nil {
-		return nil, fmt.Errorf("error occured while running query: %s, error: %v", query, err)
+func NewRepository(db *sql.DB, opts ...Option) *Repository {
+	r := &Repository{
+		Repository:         repository.New[model.Book](db, "books", "book_id"),
+		deferredJoinOffset: DefaultDeferredJoinOffset,
 	}
-	defer rows.Close()
-
-	var books []model.Book
-	for rows.Next() {
-		var book model.Book
-		if err = rows.Scan(&book.Id, &book.Author, &book.Title, &book.CreatedAt); err != nil {
-			return nil, fmt.Errorf("error while parsing rows: %v", err)
-		}
-		books = append(books, book)
+	for _, opt := range opts {
+		opt(r)
 	}
+	return r
+}
+
+func (r Repository) FetchAll(ctx context.Context, limit, offset int) ([]model.Book, error) {
+	log.Printf("fetching books with offset: %d and limit: %d", offset, limit)
+	if r.deferredJoinOffset > 0 && offset >= r.deferredJoinOffset {
+		return r.FetchOffsetDeferred(ctx, limit, offset)
+	}
+	return r.FetchOffset(ctx, limit, offset)
+}
+
+// FetchAllAsOf pages through the books created at or before asOf, so pages of
+// a snapshot are not shifted by books inserted later. It hides inserts only:
+// deletes still shift the pages and updates show.
+func (r Repository) FetchAllAsOf(ctx context.Context, asOf time.Time, limit, offset int) ([]model.Book, error) {
+	log.Printf("fetching books created until %s with offset: %d and limit: %d", asOf.Format(time.RFC3339Nano), offset, limit)
+	return r.FetchOffsetAsOf(ctx, "created_at", asOf, limit, offset)
+}
+
+// Changes returns the changes of books after the position, see
+// repository.FetchChanges.
+func (r Repository) Changes(ctx context.Context, after repository.ChangePosition, limit int) ([]repository.Change[model.Book], error) {
+	log.Printf("fetching changes of books after %d/%d with limit: %d", after.Txid, after.Id, limit)
+	return r.FetchChanges(ctx, "books", after, limit)
+}
 
-	return books, nil
+// LatestChange returns the position of the last change of books, see
+// repository.FetchLatestChange.
+func (r Repository) LatestChange(ctx context.Context) (repository.ChangePosition, error) {
+	log.Printf("fetching the latest change of books")
+	return r.FetchLatestChange(ctx, "books")
 }
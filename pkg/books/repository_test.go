@@ -1,11 +1,17 @@
 package books_test
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/krukkrz/pagination/pkg/books"
+	"github.com/krukkrz/pagination/pkg/database"
+	"github.com/krukkrz/pagination/pkg/generator"
 	_ "github.com/proullon/ramsql/driver"
+	"os"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestFetchAll(t *testing.T) {
@@ -50,7 +56,7 @@ func TestFetchAll(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			repo := books.NewRepository(db)
 
-			actual, err := repo.FetchAll(tc.limit, tc.offset)
+			actual, err := repo.FetchAll(context.Background(), tc.limit, tc.offset)
 			if err != nil {
 				t.Fatalf("unexpected error occured: %v", err)
 			}
@@ -68,23 +74,50 @@ func TestFetchAll(t *testing.T) {
 	}
 }
 
+func TestDeferredJoin(t *testing.T) {
+	if os.Getenv("PAGINATION_TEST_POSTGRES") == "" {
+		t.Skip("ramsql can not join a subquery, set PAGINATION_TEST_POSTGRES to run against the database of make start_db")
+	}
+	db, err := database.Connect(database.DefaultConfig)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	defer db.Close()
+	// a temporary table shadows the books table within its connection only,
+	// so the test runs on a single connection and leaves the real books alone
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TEMPORARY TABLE books (book_id BIGINT PRIMARY KEY, title VARCHAR ( 100 ) NOT NULL, author VARCHAR ( 100 ) NOT NULL, created_at TIMESTAMP NOT NULL);`); err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+	// generated books have ties in created_at and gaps in their ids
+	if err := generator.NewLoader(db, generator.Insert, 500).LoadBooks(context.Background(), generator.DefaultConfig, 3000); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	naive := books.NewRepository(db, books.WithDeferredJoin(0))
+	deferred := books.NewRepository(db, books.WithDeferredJoin(1000))
+	for _, page := range []struct{ limit, offset int }{{10, 1000}, {25, 1499}, {100, 2950}, {10, 5000}} {
+		expected, err := naive.FetchAll(context.Background(), page.limit, page.offset)
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		actual, err := deferred.FetchAll(context.Background(), page.limit, page.offset)
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("deferred join returned different books for limit %d and offset %d, got: %v, expected: %v", page.limit, page.offset, actual, expected)
+		}
+	}
+}
+
 func initDatabaseData(t *testing.T, db *sql.DB) {
-	initTable := `CREATE TABLE if NOT EXISTS books (book_id serial PRIMARY KEY, title VARCHAR ( 100 ) NOT NULL, author VARCHAR ( 100 ) NOT NULL, created_at TIMESTAMP NOT NULL);`
-	initialBatch := []string{
-		initTable,
-		insertBook(1),
-		insertBook(2),
-		insertBook(3),
-		insertBook(4),
-		insertBook(5),
-		insertBook(6),
-		insertBook(7),
-		insertBook(8),
-		insertBook(9),
-		insertBook(10),
+	initTable := `CREATE TABLE if NOT EXISTS books (book_id BIGSERIAL PRIMARY KEY, title VARCHAR ( 100 ) NOT NULL, author VARCHAR ( 100 ) NOT NULL, created_at TIMESTAMP NOT NULL);`
+	if _, err := db.Exec(initTable); err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
 	}
-	for _, b := range initialBatch {
-		_, err := db.Exec(b)
+	for id := 1; id <= 10; id++ {
+		_, err := db.Exec(insertBook(id), time.Date(2023, 3, 23, 19, 0, 0, 0, time.UTC))
 		if err != nil {
 			t.Fatalf("sql.Exec: Error: %s\n", err)
 		}
@@ -92,5 +125,34 @@ func initDatabaseData(t *testing.T, db *sql.DB) {
 }
 
 func insertBook(id int) string {
-	return fmt.Sprintf("INSERT INTO books (book_id, title, author, created_at) VALUES (%d, 'Title-%d', 'Author-%d', current_timestamp);", id, id, id)
+	return fmt.Sprintf("INSERT INTO books (title, author, created_at) VALUES ('Title-%d', 'Author-%d', $1);", id, id)
+}
+
+func TestFetchAllAsOf(t *testing.T) {
+	db, err := sql.Open("ramsql", "Test books as of")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+	initDatabaseData(t, db)
+	asOf := time.Date(2023, 3, 23, 19, 0, 0, 0, time.UTC)
+	for id := 11; id <= 13; id++ {
+		if _, err := db.Exec(insertBook(id), asOf.Add(time.Minute)); err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	repo := books.NewRepository(db)
+	actual, err := repo.FetchAllAsOf(context.Background(), asOf, 5, 8)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	var ids []int
+	for _, book := range actual {
+		ids = append(ids, book.Id)
+	}
+	if expected := []int{9, 10}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expecting books created later to be hidden, got: %v, expected: %v", ids, expected)
+	}
 }
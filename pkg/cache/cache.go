@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Key is a normalized page request. Two requests for the same page of the
+// same resource always produce equal keys.
+type Key struct {
+	Resource string
+	Strategy string
+	Cursor   int
+	Offset   int
+	Limit    int
+	Filters  string
+	Sort     string
+}
+
+type Config struct {
+	TTL      time.Duration
+	MaxBytes int64
+}
+
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// Cache is a least recently used cache of pages bounded by the total size of
+// its entries. Entries expire after the configured TTL.
+type Cache struct {
+	config Config
+
+	mu      sync.Mutex
+	entries map[Key]*list.Element
+	order   *list.List
+	bytes   int64
+	// generations counts the invalidations of every resource.
+	generations map[string]uint64
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+type entry struct {
+	key       Key
+	value     any
+	size      int64
+	expiresAt time.Time
+}
+
+func New(config Config) *Cache {
+	return &Cache{
+		config:      config,
+		entries:     make(map[Key]*list.Element),
+		order:       list.New(),
+		generations: make(map[string]uint64),
+	}
+}
+
+func (c *Cache) Get(key Key) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+	e := element.Value.(*entry)
+	if time.Now().After(e.expiresAt) {
+		c.remove(element)
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	c.hits.Add(1)
+	return e.value, true
+}
+
+func (c *Cache) Set(key Key, value any, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, value, size)
+}
+
+// Generation returns how many times the pages of the resource were
+// invalidated. Read it before loading a page and cache the page with SetAt.
+func (c *Cache) Generation(resource string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generations[resource]
+}
+
+// SetAt caches a page loaded at the generation of its resource, unless the
+// resource was invalidated since, in which case the page may be stale.
+func (c *Cache) SetAt(generation uint64, key Key, value any, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generations[key.Resource] != generation {
+		return
+	}
+	c.set(key, value, size)
+}
+
+func (c *Cache) set(key Key, value any, size int64) {
+	if size > c.config.MaxBytes {
+		return
+	}
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	c.entries[key] = c.order.PushFront(&entry{
+		key:       key,
+		value:     value,
+		size:      size,
+		expiresAt: time.Now().Add(c.config.TTL),
+	})
+	c.bytes += size
+
+	for c.bytes > c.config.MaxBytes {
+		c.remove(c.order.Back())
+		c.evictions.Add(1)
+	}
+}
+
+// Invalidate drops every cached page of the resource.
+func (c *Cache) Invalidate(resource string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generations[resource]++
+	for key, element := range c.entries {
+		if key.Resource == resource {
+			c.remove(element)
+		}
+	}
+}
+
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Entries:   len(c.entries),
+		Bytes:     c.bytes,
+	}
+}
+
+func (c *Cache) remove(element *list.Element) {
+	e := c.order.Remove(element).(*entry)
+	delete(c.entries, e.key)
+	c.bytes -= e.size
+}
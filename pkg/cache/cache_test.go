@@ -0,0 +1,135 @@
+package cache_test
+
+import (
+	"context"
+	"github.com/krukkrz/pagination/pkg/cache"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	books := func(offset int) cache.Key {
+		return cache.Key{Resource: "books", Strategy: "offset", Offset: offset, Limit: 10}
+	}
+	cars := cache.Key{Resource: "cars", Strategy: "cursor", Cursor: 1, Limit: 10}
+
+	t.Run("evicts least recently used pages above the size limit", func(t *testing.T) {
+		c := cache.New(cache.Config{TTL: time.Minute, MaxBytes: 30})
+		c.Set(books(0), "first", 10)
+		c.Set(books(10), "second", 10)
+		c.Set(books(20), "third", 10)
+		c.Get(books(0))
+		c.Set(books(30), "fourth", 10)
+
+		if _, ok := c.Get(books(10)); ok {
+			t.Errorf("expecting least recently used page to be evicted")
+		}
+		for _, offset := range []int{0, 20, 30} {
+			if _, ok := c.Get(books(offset)); !ok {
+				t.Errorf("expecting page with offset %d to be cached", offset)
+			}
+		}
+		if stats := c.Stats(); stats.Evictions != 1 || stats.Bytes != 30 || stats.Entries != 3 {
+			t.Errorf("unexpected stats: %+v", stats)
+		}
+	})
+
+	t.Run("does not cache pages bigger than the whole cache", func(t *testing.T) {
+		c := cache.New(cache.Config{TTL: time.Minute, MaxBytes: 30})
+		c.Set(books(0), "huge", 31)
+
+		if _, ok := c.Get(books(0)); ok {
+			t.Errorf("expecting oversized page not to be cached")
+		}
+	})
+
+	t.Run("expires pages after TTL", func(t *testing.T) {
+		c := cache.New(cache.Config{TTL: 10 * time.Millisecond, MaxBytes: 30})
+		c.Set(books(0), "first", 10)
+		time.Sleep(20 * time.Millisecond)
+
+		if _, ok := c.Get(books(0)); ok {
+			t.Errorf("expecting page to expire")
+		}
+		if stats := c.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+			t.Errorf("unexpected stats: %+v", stats)
+		}
+	})
+
+	t.Run("invalidates only pages of the resource", func(t *testing.T) {
+		c := cache.New(cache.Config{TTL: time.Minute, MaxBytes: 30})
+		c.Set(books(0), "first", 10)
+		c.Set(books(10), "second", 10)
+		c.Set(cars, "cars", 10)
+		c.Invalidate("books")
+
+		if _, ok := c.Get(books(0)); ok {
+			t.Errorf("expecting books to be invalidated")
+		}
+		if _, ok := c.Get(cars); !ok {
+			t.Errorf("expecting cars to stay cached")
+		}
+		if stats := c.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+			t.Errorf("unexpected stats: %+v", stats)
+		}
+	})
+}
+
+type countingCarRepository struct {
+	calls int
+	// loading runs while a page is loaded
+	loading func()
+}
+
+func (r *countingCarRepository) FetchAll(_ context.Context, cursor, limit int) ([]carsModels.Car, error) {
+	r.calls++
+	if r.loading != nil {
+		r.loading()
+	}
+	return []carsModels.Car{{Id: cursor}}, nil
+}
+
+func TestCachedRepository(t *testing.T) {
+	c := cache.New(cache.Config{TTL: time.Minute, MaxBytes: 1 << 20})
+	next := &countingCarRepository{}
+	repo := cache.Cars(next, c)
+
+	for i := 0; i < 3; i++ {
+		cars, err := repo.FetchAll(context.Background(), 5, 10)
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		if len(cars) != 1 || cars[0].Id != 5 {
+			t.Errorf("unexpected cars returned: %+v", cars)
+		}
+	}
+	if _, err := repo.FetchAll(context.Background(), 6, 10); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	if next.calls != 2 {
+		t.Errorf("expecting 2 calls to the underlying repository, got: %d", next.calls)
+	}
+	if stats := c.Stats(); stats.Hits != 2 || stats.Misses != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestCachedRepositoryInvalidatedWhileLoading(t *testing.T) {
+	c := cache.New(cache.Config{TTL: time.Minute, MaxBytes: 1 << 20})
+	next := &countingCarRepository{}
+	next.loading = func() {
+		c.Invalidate("cars")
+	}
+	repo := cache.Cars(next, c)
+
+	for i := 0; i < 2; i++ {
+		if _, err := repo.FetchAll(context.Background(), 5, 10); err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+	}
+	if next.calls != 2 {
+		t.Errorf("expecting the page loaded during a write not to be cached, got %d calls", next.calls)
+	}
+}
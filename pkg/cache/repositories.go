@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"log"
+)
+
+type BookRepository interface {
+	FetchAll(ctx context.Context, limit, offset int) ([]booksModels.Book, error)
+}
+
+type CarRepository interface {
+	FetchAll(ctx context.Context, cursor, limit int) ([]carsModels.Car, error)
+}
+
+type CachedBookRepository struct {
+	next  BookRepository
+	cache *Cache
+}
+
+func Books(next BookRepository, cache *Cache) *CachedBookRepository {
+	return &CachedBookRepository{next: next, cache: cache}
+}
+
+func (r CachedBookRepository) FetchAll(ctx context.Context, limit, offset int) ([]booksModels.Book, error) {
+	key := Key{Resource: "books", Strategy: "offset", Offset: offset, Limit: limit}
+	return fetch(r.cache, key, func() ([]booksModels.Book, error) {
+		return r.next.FetchAll(ctx, limit, offset)
+	})
+}
+
+type CachedCarRepository struct {
+	next  CarRepository
+	cache *Cache
+}
+
+func Cars(next CarRepository, cache *Cache) *CachedCarRepository {
+	return &CachedCarRepository{next: next, cache: cache}
+}
+
+func (r CachedCarRepository) FetchAll(ctx context.Context, cursor, limit int) ([]carsModels.Car, error) {
+	key := Key{Resource: "cars", Strategy: "cursor", Cursor: cursor, Limit: limit}
+	return fetch(r.cache, key, func() ([]carsModels.Car, error) {
+		return r.next.FetchAll(ctx, cursor, limit)
+	})
+}
+
+func fetch[T any](cache *Cache, key Key, load func() ([]T, error)) ([]T, error) {
+	if value, ok := cache.Get(key); ok {
+		return append([]T(nil), value.([]T)...), nil
+	}
+
+	// a write invalidating the resource while the page loads makes it stale
+	generation := cache.Generation(key.Resource)
+	items, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	// the encoded size is close enough to what the page takes in memory
+	encoded, err := json.Marshal(items)
+	if err != nil {
+		log.Printf("not caching page %+v: %v", key, err)
+		return items, nil
+	}
+	cache.SetAt(generation, key, append([]T(nil), items...), int64(len(encoded)))
+	return items, nil
+}
@@ -1,8 +1,10 @@
 package model
 
+import "time"
+
 type Car struct {
-	Id        int
-	Brand     string
-	Model     string
-	CreatedAt string
+	Id        int       `json:"id" db:"car_id"`
+	Brand     string    `json:"brand" db:"brand"`
+	Model     string    `json:"model" db:"model"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
@@ -1,40 +1,53 @@
 package cars
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
 	"github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/repository"
+	"github.com/krukkrz/pagination/pkg/scan"
 	"log"
+	"time"
 )
 
 type Repository struct {
-	db *sql.DB
+	*repository.Repository[model.Car]
 }
 
 func NewRepository(db *sql.DB) *Repository {
 	return &Repository{
-		db: db,
+		Repository: repository.New[model.Car](db, "cars", "car_id"),
 	}
 }
 
-func (r Repository) FetchAll(cursor, limit int) ([]model.Car, error) {
+func (r Repository) FetchAll(ctx context.Context, cursor, limit int) ([]model.Car, error) {
 	log.Printf("fetching cars with cursor: %d and limit: %d", cursor, limit)
-	query := "SELECT * FROM cars WHERE car_id >= $1 LIMIT $2;"
+	return r.FetchCursor(ctx, cursor, limit)
+}
 
-	rows, err := r.db.Query(query, cursor, limit)
-	if err != nil {
-		return nil, fmt.Errorf("error occured while running query: %s, error: %v", query, err)
-	}
-	defer rows.Close()
-
-	var cars []model.Car
-	for rows.Next() {
-		var car model.Car
-		if err = rows.Scan(&car.Id, &car.Brand, &car.Model, &car.CreatedAt); err != nil {
-			return nil, fmt.Errorf("error while parsing rows: %v", err)
-		}
-		cars = append(cars, car)
-	}
+// Scan declares a cursor over every car, see repository.DeclareCursor.
+func (r Repository) Scan(ctx context.Context) (scan.Cursor[model.Car], error) {
+	log.Printf("declaring a cursor over cars")
+	return r.DeclareCursor(ctx)
+}
+
+// FetchCreated returns the cars created within the range, oldest first, see
+// repository.FetchRange.
+func (r Repository) FetchCreated(ctx context.Context, rng repository.Range, after *repository.Position, limit int) ([]model.Car, error) {
+	log.Printf("fetching cars created since %s until %s after %v with limit: %d", rng.Since.Format(time.RFC3339Nano), rng.Until.Format(time.RFC3339Nano), after, limit)
+	return r.FetchRange(ctx, "created_at", rng, after, limit)
+}
+
+// Changes returns the changes of cars after the position, see
+// repository.FetchChanges.
+func (r Repository) Changes(ctx context.Context, after repository.ChangePosition, limit int) ([]repository.Change[model.Car], error) {
+	log.Printf("fetching changes of cars after %d/%d with limit: %d", after.Txid, after.Id, limit)
+	return r.FetchChanges(ctx, "cars", after, limit)
+}
 
-	return cars, nil
+// LatestChange returns the position of the last change of cars, see
+// repository.FetchLatestChange.
+func (r Repository) LatestChange(ctx context.Context) (repository.ChangePosition, error) {
+	log.Printf("fetching the latest change of cars")
+	return r.FetchLatestChange(ctx, "cars")
 }
@@ -1,11 +1,13 @@
 package cars_test
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/krukkrz/pagination/pkg/cars"
 	_ "github.com/proullon/ramsql/driver"
 	"testing"
+	"time"
 )
 
 func TestFetchAll(t *testing.T) {
@@ -55,7 +57,7 @@ func TestFetchAll(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			repo := cars.NewRepository(db)
 
-			actual, err := repo.FetchAll(tc.cursor, tc.limit)
+			actual, err := repo.FetchAll(context.Background(), tc.cursor, tc.limit)
 			if err != nil {
 				t.Fatalf("unexpected error occured: %v", err)
 			}
@@ -80,22 +82,12 @@ func TestFetchAll(t *testing.T) {
 }
 
 func initDatabaseData(t *testing.T, db *sql.DB) {
-	initTable := `CREATE TABLE if NOT EXISTS cars (car_id serial PRIMARY KEY, brand VARCHAR ( 100 ) NOT NULL, model VARCHAR ( 100 ) NOT NULL, created_at TIMESTAMP NOT NULL);`
-	initialBatch := []string{
-		initTable,
-		insertCar(1),
-		insertCar(2),
-		insertCar(3),
-		insertCar(4),
-		insertCar(5),
-		insertCar(6),
-		insertCar(7),
-		insertCar(8),
-		insertCar(9),
-		insertCar(10),
+	initTable := `CREATE TABLE if NOT EXISTS cars (car_id BIGSERIAL PRIMARY KEY, brand VARCHAR ( 100 ) NOT NULL, model VARCHAR ( 100 ) NOT NULL, created_at TIMESTAMP NOT NULL);`
+	if _, err := db.Exec(initTable); err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
 	}
-	for _, b := range initialBatch {
-		_, err := db.Exec(b)
+	for id := 1; id <= 10; id++ {
+		_, err := db.Exec(insertCar(id), time.Date(2023, 3, 23, 19, 0, 0, 0, time.UTC))
 		if err != nil {
 			t.Fatalf("sql.Exec: Error: %s\n", err)
 		}
@@ -103,5 +95,5 @@ func initDatabaseData(t *testing.T, db *sql.DB) {
 }
 
 func insertCar(id int) string {
-	return fmt.Sprintf("INSERT INTO cars (car_id, brand, model, created_at) VALUES (%d, 'Brand-%d', 'Model-%d', current_timestamp);", id, id, id)
+	return fmt.Sprintf("INSERT INTO cars (brand, model, created_at) VALUES ('Brand-%d', 'Model-%d', $1);", id, id)
 }
@@ -0,0 +1,107 @@
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Loader returns the key of every nth row in key order, starting with the
+// first row.
+type Loader func(ctx context.Context, every int) ([]int, error)
+
+// Index keeps the key at every nth row of a table, so a page deep in the
+// table can be turned into a seek from the nearest checkpoint. The index is
+// only as fresh as its last refresh: rows inserted or deleted since then shift
+// the pages it points at. An index covers a single sort order, the one of its
+// loader.
+type Index struct {
+	load  Loader
+	every int
+
+	mu      sync.RWMutex
+	keys    []int
+	builtAt time.Time
+}
+
+// Seek tells where the row at a position is found: skip rows after the row
+// with key From.
+type Seek struct {
+	From    int
+	Skip    int
+	BuiltAt time.Time
+}
+
+// New returns an index keeping the key at every nth row, it panics when every
+// is lower than 1.
+func New(load Loader, every int) *Index {
+	if every < 1 {
+		panic(fmt.Sprintf("checkpoint: every must be at least 1, got: %d", every))
+	}
+	return &Index{load: load, every: every}
+}
+
+// Refresh reloads the checkpoints.
+func (i *Index) Refresh(ctx context.Context) error {
+	started := time.Now()
+	keys, err := i.load(ctx, i.every)
+	if err != nil {
+		return fmt.Errorf("error while loading checkpoints: %v", err)
+	}
+	i.mu.Lock()
+	i.keys = keys
+	i.builtAt = started
+	i.mu.Unlock()
+	log.Printf("loaded %d checkpoints in %v", len(keys), time.Since(started))
+	return nil
+}
+
+// Run refreshes the checkpoints every interval until the context is done.
+func (i *Index) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := i.Refresh(ctx); err != nil {
+				log.Printf("keeping checkpoints built at %s: %v", i.BuiltAt().Format(time.RFC3339), err)
+			}
+		}
+	}
+}
+
+// Seek returns the nearest checkpoint at or before the row at position, which
+// counts from 0. It returns false until the index was built once.
+func (i *Index) Seek(position int) (Seek, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.builtAt.IsZero() {
+		return Seek{}, false
+	}
+	if position < 0 {
+		position = 0
+	}
+	if len(i.keys) == 0 {
+		// an empty table, any seek finds nothing
+		return Seek{BuiltAt: i.builtAt}, true
+	}
+	checkpoint := position / i.every
+	if checkpoint >= len(i.keys) {
+		checkpoint = len(i.keys) - 1
+	}
+	return Seek{
+		From:    i.keys[checkpoint],
+		Skip:    position - checkpoint*i.every,
+		BuiltAt: i.builtAt,
+	}, true
+}
+
+func (i *Index) BuiltAt() time.Time {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.builtAt
+}
@@ -0,0 +1,73 @@
+package checkpoint_test
+
+import (
+	"context"
+	"errors"
+	"github.com/krukkrz/pagination/pkg/checkpoint"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	// keys of a table with gaps, every third row is a checkpoint
+	keys := []int{1, 2, 5, 6, 7, 10, 11, 12, 20, 21}
+	load := func(ctx context.Context, every int) ([]int, error) {
+		var checkpoints []int
+		for i := 0; i < len(keys); i += every {
+			checkpoints = append(checkpoints, keys[i])
+		}
+		return checkpoints, nil
+	}
+	index := checkpoint.New(load, 3)
+
+	if _, ok := index.Seek(0); ok {
+		t.Errorf("expecting no seek before the index is built")
+	}
+	if err := index.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	testCases := []struct {
+		position     int
+		expectedFrom int
+		expectedSkip int
+	}{
+		{position: -1000, expectedFrom: 1, expectedSkip: 0},
+		{position: 0, expectedFrom: 1, expectedSkip: 0},
+		{position: 4, expectedFrom: 6, expectedSkip: 1},
+		{position: 6, expectedFrom: 11, expectedSkip: 0},
+		{position: 9, expectedFrom: 21, expectedSkip: 0},
+		{position: 14, expectedFrom: 21, expectedSkip: 5},
+	}
+	for _, tc := range testCases {
+		seek, ok := index.Seek(tc.position)
+		if !ok {
+			t.Fatalf("expecting seek once the index is built")
+		}
+		if seek.From != tc.expectedFrom || seek.Skip != tc.expectedSkip {
+			t.Errorf("unexpected seek of position %d, got: %+v, expected from %d skipping %d", tc.position, seek, tc.expectedFrom, tc.expectedSkip)
+		}
+		if seek.BuiltAt.IsZero() {
+			t.Errorf("expecting seek to tell when the index was built")
+		}
+	}
+
+	t.Run("failed refresh keeps the previous checkpoints", func(t *testing.T) {
+		failing := checkpoint.New(func(ctx context.Context, every int) ([]int, error) {
+			return nil, errors.New("connection refused")
+		}, 3)
+		if err := failing.Refresh(context.Background()); err == nil {
+			t.Errorf("expecting refresh to fail")
+		}
+		if _, ok := failing.Seek(0); ok {
+			t.Errorf("expecting no seek from an index never built")
+		}
+	})
+	t.Run("rejects less than one row between checkpoints", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expecting New to panic")
+			}
+		}()
+		checkpoint.New(load, 0)
+	})
+}
@@ -0,0 +1,188 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/contract"
+	"github.com/krukkrz/pagination/pkg/problem"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls the v2 routes of the pagination service.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	apiKey     string
+	retry      RetryPolicy
+}
+
+type Option func(*Client)
+
+// RetryPolicy retries requests answered with 429 or a 5xx status. Backoff
+// starts at InitialBackoff and doubles up to MaxBackoff, unless the server
+// asks for a longer wait with Retry-After.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+var defaultRetryPolicy = RetryPolicy{MaxAttempts: 4, InitialBackoff: 100 * time.Millisecond, MaxBackoff: 2 * time.Second}
+
+// StatusError is returned for responses which are neither successful nor worth
+// retrying, or which kept failing after the last attempt.
+type StatusError struct {
+	StatusCode int
+	Detail     string
+}
+
+func (e *StatusError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("unexpected status: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("unexpected status: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Detail)
+}
+
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url %s: %v", baseURL, err)
+	}
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		retry:      defaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIKey sends the key as a bearer token with every request.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+func (c *Client) ListBooks(ctx context.Context, limit, offset int) (contract.PageResponse[booksModels.Book], error) {
+	return get[booksModels.Book](ctx, c, booksPath(limit, offset))
+}
+
+func (c *Client) ListCars(ctx context.Context, cursor, limit int) (contract.PageResponse[carsModels.Car], error) {
+	return get[carsModels.Car](ctx, c, carsPath(cursor, limit))
+}
+
+func booksPath(limit, offset int) string {
+	return fmt.Sprintf("/v2/books?limit=%d&offset=%d", limit, offset)
+}
+
+func carsPath(cursor, limit int) string {
+	return fmt.Sprintf("/v2/cars?cursor=%d&limit=%d", cursor, limit)
+}
+
+// get fetches a page from a path relative to the base url, as found in the
+// links of a page.
+func get[T any](ctx context.Context, c *Client, path string) (contract.PageResponse[T], error) {
+	var page contract.PageResponse[T]
+	ref, err := url.Parse(path)
+	if err != nil {
+		return page, fmt.Errorf("invalid page link %s: %v", path, err)
+	}
+	target := c.baseURL.ResolveReference(ref).String()
+
+	backoff := c.retry.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := c.do(ctx, target)
+		if err != nil {
+			return page, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			err = json.NewDecoder(resp.Body).Decode(&page)
+			resp.Body.Close()
+			if err != nil {
+				return page, fmt.Errorf("error while parsing page %s: %v", path, err)
+			}
+			return page, nil
+		}
+
+		statusErr := statusError(resp)
+		wait := retryAfter(resp, backoff)
+		resp.Body.Close()
+		if !retryable(resp.StatusCode) || attempt >= c.retry.MaxAttempts {
+			return page, statusErr
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return page, ctx.Err()
+		}
+		backoff *= 2
+		if backoff > c.retry.MaxBackoff {
+			backoff = c.retry.MaxBackoff
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return c.httpClient.Do(req)
+}
+
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// retryAfter honours the Retry-After header when it asks for more than the
+// backoff, otherwise it jitters the backoff so clients do not retry together.
+func retryAfter(resp *http.Response, backoff time.Duration) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		if wait := time.Duration(seconds) * time.Second; wait > backoff {
+			return wait
+		}
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+func statusError(resp *http.Response) *StatusError {
+	err := &StatusError{StatusCode: resp.StatusCode}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/problem+json") {
+		var details problem.Details
+		if json.NewDecoder(resp.Body).Decode(&details) == nil {
+			err.Detail = details.Detail
+		}
+	}
+	return err
+}
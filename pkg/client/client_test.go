@@ -0,0 +1,225 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/api"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/client"
+	"github.com/krukkrz/pagination/pkg/problem"
+	"github.com/krukkrz/pagination/pkg/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var noBackoff = client.WithRetry(client.RetryPolicy{MaxAttempts: 3})
+
+func newServer(t *testing.T, size int) *httptest.Server {
+	createdAt := time.Date(2023, 3, 23, 19, 0, 0, 0, time.UTC)
+	books := memory.NewBookRepository()
+	cars := memory.NewCarRepository()
+	for i := 1; i <= size; i++ {
+		books.Insert(booksModels.Book{Id: i, Title: fmt.Sprintf("title %d", i), Author: fmt.Sprintf("author %d", i), CreatedAt: createdAt})
+		// every other car id is missing, so cursors have to follow the returned ids
+		cars.Insert(carsModels.Car{Id: 2 * i, Brand: fmt.Sprintf("brand %d", i), Model: fmt.Sprintf("model %d", i), CreatedAt: createdAt})
+	}
+	srv := httptest.NewServer(api.NewServer(books, cars).Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newClient(t *testing.T, url string, opts ...client.Option) *client.Client {
+	c, err := client.New(url, opts...)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	return c
+}
+
+func TestList(t *testing.T) {
+	c := newClient(t, newServer(t, 25).URL)
+
+	books, err := c.ListBooks(context.Background(), 10, 20)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if len(books.Data) != 5 || books.Data[0].Id != 21 || books.Links.Next != nil {
+		t.Errorf("unexpected last page of books: %+v", books)
+	}
+
+	cars, err := c.ListCars(context.Background(), 1, 10)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if len(cars.Data) != 10 || cars.Data[9].Id != 20 || cars.Links.Next == nil || *cars.Links.Next != "/v2/cars?cursor=21&limit=10" {
+		t.Errorf("unexpected first page of cars: %+v", cars)
+	}
+}
+
+func TestPager(t *testing.T) {
+	srv := newServer(t, 25)
+
+	for _, prefetch := range []bool{false, true} {
+		t.Run(fmt.Sprintf("prefetch %v", prefetch), func(t *testing.T) {
+			var opts []client.PagerOption
+			if prefetch {
+				opts = append(opts, client.WithPrefetch())
+			}
+			c := newClient(t, srv.URL)
+
+			var ids []int
+			err := c.Cars(10, opts...).All(context.Background(), func(car carsModels.Car) error {
+				ids = append(ids, car.Id)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if len(ids) != 25 || ids[0] != 2 || ids[24] != 50 {
+				t.Errorf("expecting every car to be visited once, got: %v", ids)
+			}
+		})
+	}
+
+	t.Run("returns ErrDone after the last page", func(t *testing.T) {
+		pager := newClient(t, srv.URL).Books(30)
+		if items, err := pager.Next(context.Background()); err != nil || len(items) != 25 {
+			t.Fatalf("expecting a single page of 25 books, got: %d and %v", len(items), err)
+		}
+		if pager.HasNext() {
+			t.Errorf("expecting no more pages")
+		}
+		if _, err := pager.Next(context.Background()); !errors.Is(err, client.ErrDone) {
+			t.Errorf("expecting ErrDone, got: %v", err)
+		}
+	})
+}
+
+func TestPrefetch(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		rw.Header().Set("Content-Type", "application/json")
+		if n == 1 {
+			fmt.Fprint(rw, `{"data":[{"id":1}],"links":{"next":"/v2/books?limit=1&offset=1"}}`)
+			return
+		}
+		fmt.Fprint(rw, `{"data":[{"id":2}],"links":{}}`)
+	}))
+	defer srv.Close()
+
+	pager := newClient(t, srv.URL).Books(1, client.WithPrefetch())
+	if _, err := pager.Next(context.Background()); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for requests.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if requests.Load() != 2 {
+		t.Fatalf("expecting the second page to be prefetched")
+	}
+	items, err := pager.Next(context.Background())
+	if err != nil || len(items) != 1 || items[0].Id != 2 || requests.Load() != 2 {
+		t.Errorf("expecting the prefetched page without another request, got: %v, %v after %d requests", items, err, requests.Load())
+	}
+}
+
+func TestPrefetchOfExpiredCall(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		rw.Header().Set("Content-Type", "application/json")
+		switch n {
+		case 1:
+			fmt.Fprint(rw, `{"data":[{"id":1}],"links":{"next":"/v2/books?limit=1&offset=1"}}`)
+		case 2:
+			// the prefetch hangs until the first call runs out of time
+			<-r.Context().Done()
+		default:
+			fmt.Fprint(rw, `{"data":[{"id":2}],"links":{}}`)
+		}
+	}))
+	defer srv.Close()
+
+	pager := newClient(t, srv.URL, noBackoff).Books(1, client.WithPrefetch())
+	first, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := pager.Next(first); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	<-first.Done()
+
+	items, err := pager.Next(context.Background())
+	if err != nil || len(items) != 1 || items[0].Id != 2 {
+		t.Errorf("expecting the page to be requested again, got: %v, %v", items, err)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	testCases := []struct {
+		name             string
+		failures         []int
+		expectedRequests int32
+		expectedStatus   int
+	}{
+		{
+			name:             "retries server errors",
+			failures:         []int{http.StatusServiceUnavailable, http.StatusBadGateway},
+			expectedRequests: 3,
+		},
+		{
+			name:             "retries rate limited requests",
+			failures:         []int{http.StatusTooManyRequests},
+			expectedRequests: 2,
+		},
+		{
+			name:             "gives up after the last attempt",
+			failures:         []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError},
+			expectedRequests: 3,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+		{
+			name:             "does not retry client errors",
+			failures:         []int{http.StatusForbidden},
+			expectedRequests: 1,
+			expectedStatus:   http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1))
+				if n <= len(tc.failures) {
+					rw.Header().Set("Retry-After", "0")
+					problem.Write(rw, tc.failures[n-1], "try again")
+					return
+				}
+				fmt.Fprint(rw, `{"data":[{"id":1}],"links":{}}`)
+			}))
+			defer srv.Close()
+
+			_, err := newClient(t, srv.URL, noBackoff).ListBooks(context.Background(), 1, 0)
+			if requests.Load() != tc.expectedRequests {
+				t.Errorf("unexpected number of requests, got: %d, expected: %d", requests.Load(), tc.expectedRequests)
+			}
+			if tc.expectedStatus == 0 {
+				if err != nil {
+					t.Errorf("unexpected error occured: %v", err)
+				}
+				return
+			}
+			var statusErr *client.StatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != tc.expectedStatus || statusErr.Detail != "try again" {
+				t.Errorf("expecting status error %d, got: %v", tc.expectedStatus, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/contract"
+)
+
+// ErrDone is returned by Pager.Next after the last page.
+var ErrDone = errors.New("no more pages")
+
+// Pager walks a collection by following the next link of every page until a
+// page has none.
+type Pager[T any] struct {
+	client   *Client
+	next     string
+	done     bool
+	prefetch bool
+	pending  chan result[T]
+	// pendingCtx is the context of the call to Next which started the prefetch.
+	pendingCtx context.Context
+}
+
+type result[T any] struct {
+	page contract.PageResponse[T]
+	err  error
+}
+
+type PagerOption func(*pagerConfig)
+
+type pagerConfig struct {
+	prefetch bool
+}
+
+// WithPrefetch fetches the next page in the background while the current one
+// is being handled. The prefetch runs under the context of the call to Next
+// which started it, a prefetch which failed because that context ended is
+// requested again under the context of the following call.
+func WithPrefetch() PagerOption {
+	return func(c *pagerConfig) {
+		c.prefetch = true
+	}
+}
+
+func (c *Client) Books(limit int, opts ...PagerOption) *Pager[booksModels.Book] {
+	return newPager[booksModels.Book](c, booksPath(limit, 0), opts)
+}
+
+func (c *Client) Cars(limit int, opts ...PagerOption) *Pager[carsModels.Car] {
+	return newPager[carsModels.Car](c, carsPath(1, limit), opts)
+}
+
+func newPager[T any](c *Client, first string, opts []PagerOption) *Pager[T] {
+	var config pagerConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return &Pager[T]{client: c, next: first, prefetch: config.prefetch}
+}
+
+func (p *Pager[T]) HasNext() bool {
+	return !p.done
+}
+
+// Next returns the items of the next page, or ErrDone after the last one. A
+// page which failed is requested again by the following call.
+func (p *Pager[T]) Next(ctx context.Context) ([]T, error) {
+	if p.done {
+		return nil, ErrDone
+	}
+
+	var current result[T]
+	if p.pending != nil {
+		select {
+		case current = <-p.pending:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		p.pending = nil
+		// the prefetch was cancelled, or ran out of time, together with the
+		// call which started it
+		if current.err != nil && p.pendingCtx.Err() != nil && ctx.Err() == nil {
+			current.page, current.err = get[T](ctx, p.client, p.next)
+		}
+	} else {
+		current.page, current.err = get[T](ctx, p.client, p.next)
+	}
+	if current.err != nil {
+		return nil, current.err
+	}
+
+	if current.page.Links.Next == nil {
+		p.done = true
+		return current.page.Data, nil
+	}
+	p.next = *current.page.Links.Next
+	if p.prefetch {
+		p.pending, p.pendingCtx = make(chan result[T], 1), ctx
+		go func(pending chan<- result[T], next string) {
+			page, err := get[T](ctx, p.client, next)
+			pending <- result[T]{page: page, err: err}
+		}(p.pending, p.next)
+	}
+	return current.page.Data, nil
+}
+
+// All calls fn with every item of the remaining pages.
+func (p *Pager[T]) All(ctx context.Context, fn func(T) error) error {
+	for p.HasNext() {
+		items, err := p.Next(ctx)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package coalesce
+
+import (
+	"context"
+	"sync"
+)
+
+// Group runs at most one call per key at a time. Callers asking for a key
+// which is already in flight wait for its result instead of starting their own
+// call. Every caller may give up on its own context; the shared call is
+// cancelled only once all of its callers gave up.
+type Group[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[V]
+}
+
+type call[V any] struct {
+	done    chan struct{}
+	value   V
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// Do returns the result of fn for the key, and whether it was shared with
+// other callers.
+func (g *Group[K, V]) Do(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (V, bool, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+	c, shared := g.calls[key]
+	if !shared {
+		callCtx, cancel := context.WithCancel(context.Background())
+		c = &call[V]{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go g.run(callCtx, key, c, fn)
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.value, shared, c.err
+	case <-ctx.Done():
+		g.leave(key, c)
+		var zero V
+		return zero, shared, ctx.Err()
+	}
+}
+
+func (g *Group[K, V]) run(ctx context.Context, key K, c *call[V], fn func(ctx context.Context) (V, error)) {
+	c.value, c.err = fn(ctx)
+	c.cancel()
+
+	g.mu.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	close(c.done)
+}
+
+func (g *Group[K, V]) leave(key K, c *call[V]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+	// nobody waits for the result anymore, so later callers start a new call
+	// instead of joining a cancelled one
+	c.cancel()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
@@ -0,0 +1,121 @@
+package coalesce_test
+
+import (
+	"context"
+	"errors"
+	"github.com/krukkrz/pagination/pkg/coalesce"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroup(t *testing.T) {
+	t.Run("concurrent identical calls share one result", func(t *testing.T) {
+		var group coalesce.Group[string, int]
+		var calls atomic.Int32
+		release := make(chan struct{})
+
+		var wg sync.WaitGroup
+		results := make([]int, 10)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				value, _, err := group.Do(context.Background(), "books:0:10", func(ctx context.Context) (int, error) {
+					calls.Add(1)
+					<-release
+					return 42, nil
+				})
+				if err != nil {
+					t.Errorf("unexpected error occured: %v", err)
+				}
+				results[i] = value
+			}(i)
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if calls.Load() != 1 {
+			t.Errorf("expecting a single call, got: %d", calls.Load())
+		}
+		for _, result := range results {
+			if result != 42 {
+				t.Errorf("unexpected result: %d", result)
+			}
+		}
+	})
+
+	t.Run("cancelled caller leaves without cancelling the others", func(t *testing.T) {
+		var group coalesce.Group[string, int]
+		release := make(chan struct{})
+		fn := func(ctx context.Context) (int, error) {
+			select {
+			case <-release:
+				return 42, nil
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		}
+
+		patient := make(chan error)
+		go func() {
+			_, _, err := group.Do(context.Background(), "page", fn)
+			patient <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		impatient := make(chan error)
+		go func() {
+			_, shared, err := group.Do(ctx, "page", fn)
+			if !shared {
+				t.Errorf("expecting second caller to join the first call")
+			}
+			impatient <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+
+		if err := <-impatient; !errors.Is(err, context.Canceled) {
+			t.Errorf("expecting cancelled caller to get context error, got: %v", err)
+		}
+		close(release)
+		if err := <-patient; err != nil {
+			t.Errorf("expecting remaining caller to get the result, got: %v", err)
+		}
+	})
+
+	t.Run("call is cancelled once every caller left", func(t *testing.T) {
+		var group coalesce.Group[string, int]
+		callCancelled := make(chan struct{})
+		ctx, cancel := context.WithCancel(context.Background())
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+		_, _, err := group.Do(ctx, "page", func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			close(callCancelled)
+			return 0, ctx.Err()
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expecting context error, got: %v", err)
+		}
+
+		select {
+		case <-callCancelled:
+		case <-time.After(time.Second):
+			t.Fatalf("expecting shared call to be cancelled")
+		}
+
+		value, shared, err := group.Do(context.Background(), "page", func(ctx context.Context) (int, error) {
+			return 7, nil
+		})
+		if err != nil || shared || value != 7 {
+			t.Errorf("expecting a fresh call after the cancelled one, got: %d, %v, %v", value, shared, err)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package coalesce
+
+import (
+	"context"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+)
+
+type BookRepository interface {
+	FetchAll(ctx context.Context, limit, offset int) ([]booksModels.Book, error)
+}
+
+type CarRepository interface {
+	FetchAll(ctx context.Context, cursor, limit int) ([]carsModels.Car, error)
+}
+
+type page struct {
+	first int
+	limit int
+}
+
+type CoalescedBookRepository struct {
+	next  BookRepository
+	group Group[page, []booksModels.Book]
+}
+
+func Books(next BookRepository) *CoalescedBookRepository {
+	return &CoalescedBookRepository{next: next}
+}
+
+func (r *CoalescedBookRepository) FetchAll(ctx context.Context, limit, offset int) ([]booksModels.Book, error) {
+	books, _, err := r.group.Do(ctx, page{first: offset, limit: limit}, func(ctx context.Context) ([]booksModels.Book, error) {
+		return r.next.FetchAll(ctx, limit, offset)
+	})
+	return append([]booksModels.Book(nil), books...), err
+}
+
+type CoalescedCarRepository struct {
+	next  CarRepository
+	group Group[page, []carsModels.Car]
+}
+
+func Cars(next CarRepository) *CoalescedCarRepository {
+	return &CoalescedCarRepository{next: next}
+}
+
+func (r *CoalescedCarRepository) FetchAll(ctx context.Context, cursor, limit int) ([]carsModels.Car, error) {
+	cars, _, err := r.group.Do(ctx, page{first: cursor, limit: limit}, func(ctx context.Context) ([]carsModels.Car, error) {
+		return r.next.FetchAll(ctx, cursor, limit)
+	})
+	return append([]carsModels.Car(nil), cars...), err
+}
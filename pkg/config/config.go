@@ -0,0 +1,47 @@
+package config
+
+import (
+	"flag"
+	"github.com/krukkrz/pagination/pkg/database"
+	"github.com/krukkrz/pagination/pkg/storage"
+	"os"
+)
+
+// Config holds the settings shared by every subcommand. Each setting is read
+// from its flag, then from its PAGINATION_* environment variable, and falls
+// back to a default which works with db/docker-compose.yml.
+type Config struct {
+	Storage  string
+	Database database.Config
+	// Addr is where the API listens.
+	Addr string
+	// URL is where the API is called by the fetch subcommand.
+	URL string
+	// APIKey authenticates the calls of the fetch subcommand.
+	APIKey string
+}
+
+// Register adds the shared flags to the flag set of a subcommand. The config
+// is filled in once the flag set is parsed.
+func Register(fs *flag.FlagSet) *Config {
+	c := &Config{}
+	fs.StringVar(&c.Storage, "storage", Env("PAGINATION_STORAGE", storage.Postgres), "storage backend: postgres, ramsql or memory")
+	fs.StringVar(&c.Database.Host, "db-host", Env("PAGINATION_DB_HOST", database.DefaultConfig.Host), "postgres host")
+	fs.StringVar(&c.Database.Port, "db-port", Env("PAGINATION_DB_PORT", database.DefaultConfig.Port), "postgres port")
+	fs.StringVar(&c.Database.User, "db-user", Env("PAGINATION_DB_USER", database.DefaultConfig.User), "postgres user")
+	fs.StringVar(&c.Database.Password, "db-password", Env("PAGINATION_DB_PASSWORD", database.DefaultConfig.Password), "postgres password")
+	fs.StringVar(&c.Database.Name, "db-name", Env("PAGINATION_DB_NAME", database.DefaultConfig.Name), "postgres database")
+	fs.StringVar(&c.Addr, "addr", Env("PAGINATION_ADDR", ":8000"), "address the API listens on")
+	fs.StringVar(&c.URL, "url", Env("PAGINATION_URL", "http://localhost:8000"), "base url of the API called by fetch")
+	fs.StringVar(&c.APIKey, "api-key", Env("PAGINATION_API_KEY", ""), "API key sent by fetch")
+	return c
+}
+
+// Env returns the value of the environment variable, or fallback when it is
+// not set.
+func Env(name, fallback string) string {
+	if value, ok := os.LookupEnv(name); ok {
+		return value
+	}
+	return fallback
+}
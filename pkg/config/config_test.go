@@ -0,0 +1,30 @@
+package config_test
+
+import (
+	"flag"
+	"github.com/krukkrz/pagination/pkg/config"
+	"github.com/krukkrz/pagination/pkg/database"
+	"github.com/krukkrz/pagination/pkg/storage"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	t.Setenv("PAGINATION_STORAGE", storage.Memory)
+	t.Setenv("PAGINATION_DB_HOST", "db.example.com")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg := config.Register(fs)
+	if err := fs.Parse([]string{"--db-host", "override.example.com", "--addr", ":9000"}); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	if cfg.Storage != storage.Memory {
+		t.Errorf("expecting storage from the environment, got: %s", cfg.Storage)
+	}
+	if cfg.Database.Host != "override.example.com" {
+		t.Errorf("expecting flags to override the environment, got: %s", cfg.Database.Host)
+	}
+	if cfg.Database.Port != database.DefaultConfig.Port || cfg.Addr != ":9000" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+}
@@ -0,0 +1,273 @@
+package consistency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/contract"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+)
+
+// Store is the table behind the checked endpoint. The checker writes to it
+// while pages are fetched and reads its ids as the ground truth. It has to be
+// safe for concurrent use and must never reuse the id of a deleted row.
+type Store interface {
+	Ids(ctx context.Context) ([]int, error)
+	Insert(ctx context.Context) error
+	Delete(ctx context.Context, id int) error
+}
+
+type Config struct {
+	// First is the url of the first page, for example /v2/books?limit=10&offset=0.
+	First string
+	// Strategy names the pagination strategy in the report.
+	Strategy string
+	// Inserts and Deletes are the rows written while every page but the first
+	// is fetched. Deleted rows are picked at random among the existing ones.
+	Inserts int
+	Deletes int
+	Seed    int64
+	// MaxPages stops walks which never reach the last page.
+	MaxPages int
+}
+
+// Report lists what went wrong while walking an endpoint. Rows which existed
+// for the whole walk have to be returned exactly once, rows inserted or deleted
+// meanwhile may be returned once or not at all.
+type Report struct {
+	Endpoint string
+	Strategy string
+	// Seed and Writes tell how the writes interleaved with the walk, so a
+	// failure can be reproduced.
+	Seed   int64
+	Writes []Write
+	Pages  int
+	Rows   int
+	// Duplicates are ids returned more than once.
+	Duplicates []int
+	// Gaps are ids which existed for the whole walk but were never returned.
+	Gaps []int
+	// Unordered are ids returned after a greater id.
+	Unordered []int
+	// Links describes links which do not match the pages they belong to.
+	Links []string
+}
+
+// Write is a row written during the walk. Its round of writes started once
+// Pages pages were fetched and ran while the next one was.
+type Write struct {
+	Pages     int
+	Operation string
+	// Id is the id of a deleted row, inserted rows get theirs from the store.
+	Id int
+}
+
+func (w Write) String() string {
+	if w.Operation == "delete" {
+		return fmt.Sprintf("%s %d while fetching page %d", w.Operation, w.Id, w.Pages+1)
+	}
+	return fmt.Sprintf("%s while fetching page %d", w.Operation, w.Pages+1)
+}
+
+func (r Report) OK() bool {
+	return len(r.Duplicates) == 0 && len(r.Gaps) == 0 && len(r.Unordered) == 0 && len(r.Links) == 0
+}
+
+func (r Report) String() string {
+	status := "ok"
+	if !r.OK() {
+		status = "FAILED"
+	}
+	report := fmt.Sprintf("%s (%s): %s, seed %d, %d pages, %d rows, %d writes, %d duplicates %v, %d gaps %v, %d unordered %v, %d link errors %v",
+		r.Endpoint, r.Strategy, status, r.Seed, r.Pages, r.Rows, len(r.Writes), len(r.Duplicates), r.Duplicates, len(r.Gaps), r.Gaps, len(r.Unordered), r.Unordered, len(r.Links), r.Links)
+	if !r.OK() {
+		report += fmt.Sprintf(", writes %v", r.Writes)
+	}
+	return report
+}
+
+type item struct {
+	Id int `json:"id"`
+}
+
+// Check walks the v2 endpoint served by handler from the first page to the
+// last one, following next links. A writer writes to the store while every
+// page but the first is fetched.
+func Check(ctx context.Context, handler http.Handler, store Store, cfg Config) (Report, error) {
+	report := Report{Endpoint: cfg.First, Strategy: cfg.Strategy, Seed: cfg.Seed}
+	if cfg.MaxPages <= 0 {
+		cfg.MaxPages = 100000
+	}
+
+	before, err := store.Ids(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	w := &writer{store: store, rng: rand.New(rand.NewSource(cfg.Seed)), cfg: cfg, pages: make(chan int), done: make(chan struct{})}
+	go w.run(ctx)
+	stop := func() error {
+		close(w.pages)
+		<-w.done
+		report.Writes = w.writes
+		return w.err
+	}
+
+	seen := make(map[int]int)
+	visited := make(map[string]bool)
+	last := 0
+	for url := cfg.First; ; {
+		if report.Pages == cfg.MaxPages {
+			report.Links = append(report.Links, fmt.Sprintf("no last page after %d pages", report.Pages))
+			break
+		}
+		visited[url] = true
+		if report.Pages > 0 {
+			// the writer starts its round as the page is fetched, once it is
+			// done with the previous one
+			w.pages <- report.Pages
+		}
+		page, err := get(handler, url)
+		if err != nil {
+			stop()
+			return report, err
+		}
+		report.Pages++
+		report.Links = append(report.Links, checkLinks(page, url, cfg.First, report.Pages == 1)...)
+
+		for _, it := range page.Data {
+			report.Rows++
+			seen[it.Id]++
+			if seen[it.Id] == 2 {
+				report.Duplicates = append(report.Duplicates, it.Id)
+			}
+			if it.Id <= last {
+				report.Unordered = append(report.Unordered, it.Id)
+			}
+			if it.Id > last {
+				last = it.Id
+			}
+		}
+
+		if page.Links.Next == nil {
+			break
+		}
+		url = *page.Links.Next
+		if visited[url] {
+			report.Links = append(report.Links, fmt.Sprintf("next link of %s leads back to %s", page.Links.Self, url))
+			break
+		}
+	}
+	if err := stop(); err != nil {
+		return report, err
+	}
+
+	after, err := store.Ids(ctx)
+	if err != nil {
+		return report, err
+	}
+	report.Gaps = stableMissing(before, after, seen)
+	return report, nil
+}
+
+func get(handler http.Handler, url string) (contract.PageResponse[item], error) {
+	var page contract.PageResponse[item]
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+	if rr.Code != http.StatusOK {
+		return page, fmt.Errorf("unexpected status of %s: %d", url, rr.Code)
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+		return page, fmt.Errorf("error while parsing page %s: %v", url, err)
+	}
+	return page, nil
+}
+
+func checkLinks(page contract.PageResponse[item], url, first string, isFirst bool) []string {
+	var problems []string
+	if page.Links.Self != url {
+		problems = append(problems, fmt.Sprintf("self link %s of page %s", page.Links.Self, url))
+	}
+	if page.Links.First != first {
+		problems = append(problems, fmt.Sprintf("first link %s of page %s", page.Links.First, url))
+	}
+	if isFirst && page.Links.Prev != nil {
+		problems = append(problems, fmt.Sprintf("prev link %s of the first page", *page.Links.Prev))
+	}
+	if !isFirst && page.Links.Prev == nil {
+		problems = append(problems, fmt.Sprintf("no prev link on page %s", url))
+	}
+	if page.Meta.Count != len(page.Data) {
+		problems = append(problems, fmt.Sprintf("count %d of page %s with %d items", page.Meta.Count, url, len(page.Data)))
+	}
+	if full := len(page.Data) >= page.Meta.Limit; full != (page.Links.Next != nil) {
+		problems = append(problems, fmt.Sprintf("next link of page %s with %d of %d items", url, len(page.Data), page.Meta.Limit))
+	}
+	return problems
+}
+
+// writer writes a round of rows for every page number it receives, recording
+// the writes, until pages is closed. It stops writing at the first error.
+type writer struct {
+	store  Store
+	rng    *rand.Rand
+	cfg    Config
+	pages  chan int
+	done   chan struct{}
+	writes []Write
+	err    error
+}
+
+func (w *writer) run(ctx context.Context) {
+	defer close(w.done)
+	for pages := range w.pages {
+		if w.err == nil {
+			w.err = w.write(ctx, pages)
+		}
+	}
+}
+
+func (w *writer) write(ctx context.Context, pages int) error {
+	for i := 0; i < w.cfg.Inserts; i++ {
+		if err := w.store.Insert(ctx); err != nil {
+			return err
+		}
+		w.writes = append(w.writes, Write{Pages: pages, Operation: "insert"})
+	}
+	if w.cfg.Deletes == 0 {
+		return nil
+	}
+	ids, err := w.store.Ids(ctx)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < w.cfg.Deletes && len(ids) > 0; i++ {
+		j := w.rng.Intn(len(ids))
+		if err := w.store.Delete(ctx, ids[j]); err != nil {
+			return err
+		}
+		w.writes = append(w.writes, Write{Pages: pages, Operation: "delete", Id: ids[j]})
+		ids = append(ids[:j], ids[j+1:]...)
+	}
+	return nil
+}
+
+// stableMissing returns the ids present both before and after the walk which
+// were never seen. Ids are never reused, so those rows existed all along.
+func stableMissing(before, after []int, seen map[int]int) []int {
+	present := make(map[int]bool, len(after))
+	for _, id := range after {
+		present[id] = true
+	}
+	var missing []int
+	for _, id := range before {
+		if present[id] && seen[id] == 0 {
+			missing = append(missing, id)
+		}
+	}
+	sort.Ints(missing)
+	return missing
+}
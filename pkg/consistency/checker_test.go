@@ -0,0 +1,101 @@
+package consistency_test
+
+import (
+	"context"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/consistency"
+	"github.com/krukkrz/pagination/pkg/database"
+	"github.com/krukkrz/pagination/pkg/storage"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		storage string
+		inserts int
+	}{
+		{storage: storage.Memory, inserts: 1},
+		// ramsql reuses the id of a deleted last row for the next insert, so it
+		// is only checked with deletes
+		{storage: storage.Ramsql},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.storage, func(t *testing.T) {
+			store, err := storage.Open(tc.storage, database.DefaultConfig)
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			defer store.Close()
+			handler := api.NewServer(store.Books, store.Cars).Handler()
+
+			books, _ := store.Checked("books")
+			report, err := consistency.Check(context.Background(), handler, books, consistency.Config{
+				First:    "/v2/books?limit=20&offset=0",
+				Strategy: "offset",
+				Inserts:  tc.inserts,
+				Deletes:  2,
+				Seed:     1,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			// deleting rows of pages already served shifts the offsets of the following ones
+			if len(report.Gaps) == 0 || len(report.Links) != 0 {
+				t.Errorf("expecting offset pagination to skip rows, got: %s", report)
+			}
+
+			cars, _ := store.Checked("cars")
+			report, err = consistency.Check(context.Background(), handler, cars, consistency.Config{
+				First:    "/v2/cars?cursor=1&limit=20",
+				Strategy: "cursor",
+				Inserts:  tc.inserts,
+				Deletes:  2,
+				Seed:     1,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if !report.OK() || report.Pages < 10 {
+				t.Errorf("expecting cursor pagination to return every row once, got: %s", report)
+			}
+			if report.Seed != 1 || len(report.Writes) != (report.Pages-1)*(tc.inserts+2) {
+				t.Errorf("expecting the seed and a round of writes for every page but the first, got: %s", report)
+			}
+		})
+	}
+}
+
+func TestStoreNeverReusesIds(t *testing.T) {
+	store, err := storage.Open(storage.Memory, database.DefaultConfig)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	defer store.Close()
+	books, _ := store.Checked("books")
+	ctx := context.Background()
+
+	highest := func() int {
+		ids, err := books.Ids(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		max := 0
+		for _, id := range ids {
+			if id > max {
+				max = id
+			}
+		}
+		return max
+	}
+	deleted := highest()
+	if err := books.Delete(ctx, deleted); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if err := books.Insert(ctx); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if inserted := highest(); inserted <= deleted {
+		t.Errorf("expecting the inserted row to get an id after the deleted %d, got: %d", deleted, inserted)
+	}
+}
@@ -0,0 +1,106 @@
+package consistency
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/storage/memory"
+	"sync"
+	"time"
+)
+
+// memoryStore hands out ids from a counter of its own, so the id of a deleted
+// row is never reused, even when it was the highest one.
+type memoryStore[T any] struct {
+	table  *memory.Table[T]
+	newRow func(id int) T
+
+	mu     sync.Mutex
+	lastId int
+}
+
+func (s *memoryStore[T]) Ids(_ context.Context) ([]int, error) {
+	return s.table.Keys(), nil
+}
+
+func (s *memoryStore[T]) Insert(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if keys := s.table.Keys(); len(keys) > 0 && keys[len(keys)-1] > s.lastId {
+		s.lastId = keys[len(keys)-1]
+	}
+	s.lastId++
+	s.table.Insert(s.newRow(s.lastId))
+	return nil
+}
+
+func (s *memoryStore[T]) Delete(_ context.Context, id int) error {
+	s.table.Delete(id)
+	return nil
+}
+
+func newMemoryStore[T any](table *memory.Table[T], newRow func(id int) T) *memoryStore[T] {
+	s := &memoryStore[T]{table: table, newRow: newRow}
+	if keys := table.Keys(); len(keys) > 0 {
+		s.lastId = keys[len(keys)-1]
+	}
+	return s
+}
+
+func MemoryBooks(repository *memory.BookRepository) Store {
+	return newMemoryStore(repository.Table, func(id int) booksModels.Book {
+		return booksModels.Book{Id: id, Title: fmt.Sprintf("Title %d", id), Author: fmt.Sprintf("Author %d", id), CreatedAt: time.Now().UTC()}
+	})
+}
+
+func MemoryCars(repository *memory.CarRepository) Store {
+	return newMemoryStore(repository.Table, func(id int) carsModels.Car {
+		return carsModels.Car{Id: id, Brand: fmt.Sprintf("Brand %d", id), Model: fmt.Sprintf("Model %d", id), CreatedAt: time.Now().UTC()}
+	})
+}
+
+type sqlStore struct {
+	db     *sql.DB
+	table  string
+	key    string
+	insert string
+}
+
+func (s sqlStore) Ids(ctx context.Context) ([]int, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s;", s.key, s.table))
+	if err != nil {
+		return nil, fmt.Errorf("error while reading ids of %s: %v", s.table, err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error while reading ids of %s: %v", s.table, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (s sqlStore) Insert(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.insert, "checker", "checker", time.Now().UTC().Truncate(time.Microsecond))
+	return err
+}
+
+func (s sqlStore) Delete(ctx context.Context, id int) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s = $1;", s.table, s.key), id)
+	return err
+}
+
+func SQLBooks(db *sql.DB) Store {
+	return sqlStore{db: db, table: "books", key: "book_id", insert: "INSERT INTO books (title, author, created_at) VALUES ($1, $2, $3);"}
+}
+
+func SQLCars(db *sql.DB) Store {
+	return sqlStore{db: db, table: "cars", key: "car_id", insert: "INSERT INTO cars (brand, model, created_at) VALUES ($1, $2, $3);"}
+}
@@ -0,0 +1,41 @@
+// Package contract holds the v2 page envelope, which the server writes and its
+// clients read.
+package contract
+
+import "time"
+
+// PageResponse is the v2 envelope. Prev and Next are null when there is no
+// such page, and Meta describes the page which was served.
+type PageResponse[T any] struct {
+	Data  []T       `json:"data"`
+	Links PageLinks `json:"links"`
+	Meta  PageMeta  `json:"meta"`
+}
+
+type PageLinks struct {
+	Self  string  `json:"self"`
+	First string  `json:"first"`
+	Prev  *string `json:"prev"`
+	Next  *string `json:"next"`
+}
+
+type PageMeta struct {
+	Limit  int  `json:"limit"`
+	Offset *int `json:"offset,omitempty"`
+	Cursor *int `json:"cursor,omitempty"`
+	// Page is set on numbered pages.
+	Page *int `json:"page,omitempty"`
+	// Around is set on pages centred on a car, Position is the position of the
+	// car counting from 1 when counts are enabled.
+	Around   *int `json:"around,omitempty"`
+	Position *int `json:"position,omitempty"`
+	Count    int  `json:"count"`
+	// Snapshot is set on the pages of a consistent pagination.
+	Snapshot *SnapshotMeta `json:"snapshot,omitempty"`
+}
+
+type SnapshotMeta struct {
+	Token     string    `json:"token"`
+	AsOf      time.Time `json:"as_of"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,122 @@
+package cors
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	// AllowedOrigins may contain "*" to allow any origin.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// DefaultExposedHeaders are the response headers browser clients need to page
+// through the API.
+var DefaultExposedHeaders = []string{
+	"Link", "ETag", "Deprecation", "Sunset", "Retry-After",
+	"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Snapshot-Expires", "Checkpoint-Age",
+}
+
+// Validate refuses credentials for any origin, which would let every site read
+// the responses of the users it gets to visit it.
+func (c Config) Validate() error {
+	if c.AllowCredentials && contains(c.AllowedOrigins, "*") {
+		return errors.New("credentials can only be allowed for listed origins, not for \"*\"")
+	}
+	return nil
+}
+
+func DefaultConfig(origins ...string) Config {
+	return Config{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{"GET"},
+		AllowedHeaders: []string{"Authorization", "Accept", "If-None-Match"},
+		ExposedHeaders: DefaultExposedHeaders,
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+// Middleware answers preflight requests itself and adds CORS headers to the
+// responses of next. Requests from origins which are not allowed are passed
+// to next without CORS headers, so the browser blocks them. Any origin gets a
+// literal "*" without credentials, even when the config allows them.
+func Middleware(config Config, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		rw.Header().Add("Vary", "Origin")
+		if origin == "" || !config.allowsOrigin(origin) {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		if contains(config.AllowedOrigins, "*") {
+			rw.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			rw.Header().Set("Access-Control-Allow-Origin", origin)
+			if config.AllowCredentials {
+				rw.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		requestedMethod := r.Header.Get("Access-Control-Request-Method")
+		if r.Method != http.MethodOptions || requestedMethod == "" {
+			if len(config.ExposedHeaders) > 0 {
+				rw.Header().Set("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+			}
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		rw.Header().Add("Vary", "Access-Control-Request-Method")
+		rw.Header().Add("Vary", "Access-Control-Request-Headers")
+		if !contains(config.AllowedMethods, requestedMethod) {
+			rw.WriteHeader(http.StatusForbidden)
+			return
+		}
+		for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+			if header = strings.TrimSpace(header); header != "" && !containsFold(config.AllowedHeaders, header) {
+				rw.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+
+		rw.Header().Set("Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ", "))
+		if len(config.AllowedHeaders) > 0 {
+			rw.Header().Set("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
+		}
+		if config.MaxAge > 0 {
+			rw.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(config.MaxAge.Seconds())))
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (c Config) allowsOrigin(origin string) bool {
+	return contains(c.AllowedOrigins, "*") || contains(c.AllowedOrigins, origin)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package cors_test
+
+import (
+	"github.com/krukkrz/pagination/pkg/cors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	withCredentials := cors.DefaultConfig("https://app.example.com")
+	withCredentials.AllowCredentials = true
+	anyWithCredentials := cors.DefaultConfig("*")
+	anyWithCredentials.AllowCredentials = true
+
+	testCases := []struct {
+		name            string
+		config          cors.Config
+		method          string
+		headers         map[string]string
+		expectedStatus  int
+		expectedHeaders map[string]string
+	}{
+		{
+			name:           "answers preflight requests without calling the handler",
+			config:         cors.DefaultConfig("https://app.example.com"),
+			method:         "OPTIONS",
+			headers:        map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "authorization, if-none-match"},
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "GET",
+				"Access-Control-Allow-Headers": "Authorization, Accept, If-None-Match",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:            "rejects preflight of methods which are not allowed",
+			config:          cors.DefaultConfig("https://app.example.com"),
+			method:          "OPTIONS",
+			headers:         map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "DELETE"},
+			expectedStatus:  http.StatusForbidden,
+			expectedHeaders: map[string]string{"Access-Control-Allow-Methods": ""},
+		},
+		{
+			name:           "exposes pagination headers on actual requests",
+			config:         cors.DefaultConfig("*"),
+			method:         "GET",
+			headers:        map[string]string{"Origin": "https://app.example.com"},
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "*",
+				"Access-Control-Expose-Headers": "Link, ETag, Deprecation, Sunset, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Snapshot-Expires, Checkpoint-Age",
+			},
+		},
+		{
+			name:           "echoes the origin when credentials are allowed",
+			config:         withCredentials,
+			method:         "GET",
+			headers:        map[string]string{"Origin": "https://app.example.com"},
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+		{
+			name:           "never allows credentials for any origin",
+			config:         anyWithCredentials,
+			method:         "GET",
+			headers:        map[string]string{"Origin": "https://evil.example.com"},
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "",
+			},
+		},
+		{
+			name:            "ignores origins which are not allowed",
+			config:          cors.DefaultConfig("https://app.example.com"),
+			method:          "OPTIONS",
+			headers:         map[string]string{"Origin": "https://evil.example.com", "Access-Control-Request-Method": "GET"},
+			expectedStatus:  http.StatusMethodNotAllowed,
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/v2/books?limit=10&offset=0", nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			rr := httptest.NewRecorder()
+			cors.Middleware(tc.config, next).ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("middleware returned wrong status code: got %v want %v", rr.Code, tc.expectedStatus)
+			}
+			for name, expected := range tc.expectedHeaders {
+				if actual := rr.Header().Get(name); actual != expected {
+					t.Errorf("unexpected %s header, got: %q, expected: %q", name, actual, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	config := cors.DefaultConfig("https://app.example.com", "*")
+	if err := config.Validate(); err != nil {
+		t.Errorf("unexpected error occured: %v", err)
+	}
+	config.AllowCredentials = true
+	if err := config.Validate(); err == nil {
+		t.Errorf("expecting credentials for any origin to be refused")
+	}
+	config.AllowedOrigins = []string{"https://app.example.com"}
+	if err := config.Validate(); err != nil {
+		t.Errorf("unexpected error occured: %v", err)
+	}
+}
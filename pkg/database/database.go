@@ -7,31 +7,32 @@ import (
 	"log"
 )
 
-type config struct {
-	host     string
-	port     string
-	user     string
-	password string
-	dbname   string
+type Config struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
 }
 
-func (c config) String() string {
-	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", c.host, c.port, c.user, c.password, c.dbname)
+// DefaultConfig matches the database started by db/docker-compose.yml.
+var DefaultConfig = Config{
+	Host:     "localhost",
+	Port:     "5432",
+	User:     "pagination",
+	Password: "pagination",
+	Name:     "paginationdb",
 }
 
-func Connect() *sql.DB {
-	//todo read those values from env
-	cfg := config{
-		host:     "localhost",
-		port:     "5432",
-		user:     "pagination",
-		password: "pagination",
-		dbname:   "paginationdb",
-	}
+func (c Config) String() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", c.Host, c.Port, c.User, c.Password, c.Name)
+}
+
+func Connect(cfg Config) (*sql.DB, error) {
 	db, err := sql.Open("postgres", cfg.String())
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	log.Printf("Database connected!")
-	return db
+	return db, nil
 }
@@ -0,0 +1,224 @@
+package generator
+
+import (
+	"fmt"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Config shapes the generated rows. The same config always generates the same
+// rows.
+type Config struct {
+	Seed int64
+	// Start is the earliest created_at. Rows are spread over Span with more of
+	// them towards the end, like in a table which keeps growing.
+	Start time.Time
+	Span  time.Duration
+	// Ties is the share of rows created at exactly the same time as the row
+	// before them.
+	Ties float64
+	// Deleted is the share of ids without a row, as if the rows were deleted.
+	// Most gaps are single ids, some are runs of up to 100 ids.
+	Deleted float64
+	// Late is the share of rows created a little earlier than the row before
+	// them, as concurrent transactions commit out of order.
+	Late float64
+	// AfterId is the id the generated ids start after, so rows can be added to
+	// a table which already holds some.
+	AfterId int
+}
+
+var DefaultConfig = Config{
+	Seed:    1,
+	Start:   time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+	Span:    3 * 365 * 24 * time.Hour,
+	Ties:    0.05,
+	Deleted: 0.02,
+	Late:    0.01,
+}
+
+// Validate refuses shares out of range. Deleted has to stay below 1, every id
+// would be skipped otherwise.
+func (c Config) Validate() error {
+	if c.Deleted < 0 || c.Deleted >= 1 {
+		return fmt.Errorf("share of deleted ids must be at least 0 and below 1, got: %v", c.Deleted)
+	}
+	if c.Ties < 0 || c.Ties > 1 {
+		return fmt.Errorf("share of ties must be between 0 and 1, got: %v", c.Ties)
+	}
+	if c.Late < 0 || c.Late > 1 {
+		return fmt.Errorf("share of late rows must be between 0 and 1, got: %v", c.Late)
+	}
+	return nil
+}
+
+// Books calls fn with n books in order of their ids.
+func Books(cfg Config, n int, fn func(booksModels.Book) error) error {
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	authors := newZipf(rng, authorNames(rng, 500))
+	return rows(rng, cfg, n, func(id int, createdAt time.Time) error {
+		return fn(booksModels.Book{
+			Id:        id,
+			Title:     title(rng),
+			Author:    authors.next(),
+			CreatedAt: createdAt,
+		})
+	})
+}
+
+// Cars calls fn with n cars in order of their ids.
+func Cars(cfg Config, n int, fn func(carsModels.Car) error) error {
+	// cars get their own stream, so the number of books does not change them
+	rng := rand.New(rand.NewSource(cfg.Seed + 1))
+	return rows(rng, cfg, n, func(id int, createdAt time.Time) error {
+		b := pickBrand(rng)
+		return fn(carsModels.Car{
+			Id:        id,
+			Brand:     b.name,
+			Model:     b.models[rng.Intn(len(b.models))],
+			CreatedAt: createdAt,
+		})
+	})
+}
+
+// rows generates the ids and created_at of n rows, leaving gaps in the ids and
+// ties in the timestamps.
+func rows(rng *rand.Rand, cfg Config, n int, fn func(id int, createdAt time.Time) error) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	id := cfg.AfterId
+	var previous time.Time
+	for i := 0; i < n; i++ {
+		id++
+		for rng.Float64() < cfg.Deleted {
+			if rng.Float64() < 0.05 {
+				id += 1 + rng.Intn(100)
+			} else {
+				id++
+			}
+		}
+
+		createdAt := createdAt(cfg, i, n)
+		switch {
+		case i > 0 && rng.Float64() < cfg.Ties:
+			createdAt = previous
+		case i > 0 && rng.Float64() < cfg.Late:
+			createdAt = previous.Add(-time.Duration(rng.Int63n(int64(time.Minute))))
+		case createdAt.Before(previous):
+			createdAt = previous
+		}
+		// postgres keeps microseconds
+		createdAt = createdAt.Truncate(time.Microsecond)
+		previous = createdAt
+
+		if err := fn(id, createdAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createdAt places row i of n along the span. The square root makes the rows
+// denser towards the end.
+func createdAt(cfg Config, i, n int) time.Time {
+	if n <= 1 {
+		return cfg.Start
+	}
+	position := math.Sqrt(float64(i) / float64(n-1))
+	return cfg.Start.Add(time.Duration(position * float64(cfg.Span)))
+}
+
+// zipf picks values with a Zipf distribution, so a few of them are very common
+// and most are rare.
+type zipf struct {
+	values []string
+	zipf   *rand.Zipf
+}
+
+func newZipf(rng *rand.Rand, values []string) *zipf {
+	return &zipf{values: values, zipf: rand.NewZipf(rng, 1.1, 1, uint64(len(values)-1))}
+}
+
+func (z *zipf) next() string {
+	return z.values[z.zipf.Uint64()]
+}
+
+var (
+	firstNames = []string{"Agatha", "Andrzej", "Anna", "Arthur", "Chimamanda", "Daniel", "Elena", "Fyodor", "George", "Haruki", "Isabel", "James", "Jane", "Jorge", "Kazuo", "Leo", "Maria", "Mary", "Olga", "Orhan", "Stanisław", "Terry", "Toni", "Ursula", "Virginia", "Wisława", "Zadie"}
+	lastNames  = []string{"Adichie", "Atwood", "Austen", "Borges", "Christie", "Dostoevsky", "Eco", "Ferrante", "Ishiguro", "Lem", "Le Guin", "Morrison", "Murakami", "Orwell", "Pamuk", "Pratchett", "Sapkowski", "Shelley", "Smith", "Szymborska", "Tokarczuk", "Tolkien", "Tolstoy", "Woolf"}
+	adjectives = []string{"Silent", "Last", "Hidden", "Broken", "Golden", "Forgotten", "Distant", "Burning", "Quiet", "Endless", "Crimson", "Little", "Lost", "Secret", "Wild"}
+	nouns      = []string{"River", "Garden", "Empire", "Letter", "Winter", "Machine", "Island", "Daughter", "Kingdom", "Journey", "House", "Storm", "Mirror", "Harvest", "Orchard"}
+)
+
+// authorNames returns size distinct names in random order, so the most common
+// author differs between seeds.
+func authorNames(rng *rand.Rand, size int) []string {
+	var names []string
+	for _, first := range firstNames {
+		for _, last := range lastNames {
+			names = append(names, first+" "+last)
+		}
+	}
+	rng.Shuffle(len(names), func(i, j int) {
+		names[i], names[j] = names[j], names[i]
+	})
+	if size < len(names) {
+		names = names[:size]
+	}
+	return names
+}
+
+func title(rng *rand.Rand) string {
+	adjective, noun := adjectives[rng.Intn(len(adjectives))], nouns[rng.Intn(len(nouns))]
+	switch rng.Intn(4) {
+	case 0:
+		return fmt.Sprintf("The %s %s", adjective, noun)
+	case 1:
+		return fmt.Sprintf("%s of the %s %s", nouns[rng.Intn(len(nouns))], adjective, noun)
+	case 2:
+		return fmt.Sprintf("The %s %s, Part %d", adjective, noun, 1+rng.Intn(3))
+	default:
+		return fmt.Sprintf("%s %s", adjective, noun)
+	}
+}
+
+type brand struct {
+	name   string
+	weight float64
+	models []string
+}
+
+// brands are weighted roughly by their share of cars on European roads.
+var brands = []brand{
+	{"Volkswagen", 0.14, []string{"Golf", "Passat", "Polo", "Tiguan", "T-Roc"}},
+	{"Toyota", 0.11, []string{"Corolla", "Yaris", "RAV4", "C-HR", "Aygo"}},
+	{"Skoda", 0.09, []string{"Octavia", "Fabia", "Superb", "Kodiaq"}},
+	{"Ford", 0.08, []string{"Focus", "Fiesta", "Kuga", "Mondeo"}},
+	{"Opel", 0.07, []string{"Astra", "Corsa", "Insignia", "Zafira"}},
+	{"Renault", 0.07, []string{"Clio", "Megane", "Captur"}},
+	{"BMW", 0.06, []string{"3 Series", "5 Series", "X3", "X5"}},
+	{"Audi", 0.06, []string{"A3", "A4", "A6", "Q5"}},
+	{"Mercedes-Benz", 0.06, []string{"A-Class", "C-Class", "E-Class", "GLC"}},
+	{"Peugeot", 0.05, []string{"208", "308", "3008"}},
+	{"Kia", 0.05, []string{"Ceed", "Sportage", "Picanto"}},
+	{"Hyundai", 0.05, []string{"i20", "i30", "Tucson"}},
+	{"Fiat", 0.04, []string{"500", "Panda", "Tipo"}},
+	{"Dacia", 0.04, []string{"Sandero", "Duster", "Logan"}},
+	{"Volvo", 0.02, []string{"XC60", "XC90", "V60"}},
+	{"Tesla", 0.01, []string{"Model 3", "Model Y"}},
+}
+
+func pickBrand(rng *rand.Rand) brand {
+	r := rng.Float64()
+	for _, b := range brands {
+		if r < b.weight {
+			return b
+		}
+		r -= b.weight
+	}
+	return brands[len(brands)-1]
+}
@@ -0,0 +1,200 @@
+package generator_test
+
+import (
+	"context"
+	"database/sql"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/generator"
+	_ "github.com/proullon/ramsql/driver"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func generateBooks(t *testing.T, cfg generator.Config, n int) []booksModels.Book {
+	var books []booksModels.Book
+	err := generator.Books(cfg, n, func(b booksModels.Book) error {
+		books = append(books, b)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	return books
+}
+
+func TestBooks(t *testing.T) {
+	books := generateBooks(t, generator.DefaultConfig, 10000)
+
+	t.Run("same seed generates the same rows", func(t *testing.T) {
+		if !reflect.DeepEqual(books, generateBooks(t, generator.DefaultConfig, 10000)) {
+			t.Errorf("expecting identical rows for the same seed")
+		}
+		other := generator.DefaultConfig
+		other.Seed = 2
+		if reflect.DeepEqual(books, generateBooks(t, other, 10000)) {
+			t.Errorf("expecting different rows for another seed")
+		}
+	})
+
+	t.Run("ids increase with gaps", func(t *testing.T) {
+		gaps := 0
+		for i := 1; i < len(books); i++ {
+			if books[i].Id <= books[i-1].Id {
+				t.Fatalf("expecting increasing ids, got %d after %d", books[i].Id, books[i-1].Id)
+			}
+			if books[i].Id > books[i-1].Id+1 {
+				gaps++
+			}
+		}
+		if gaps < 100 || gaps > 400 {
+			t.Errorf("expecting about 2%% of ids to be missing, got %d gaps", gaps)
+		}
+	})
+
+	t.Run("ids start after AfterId", func(t *testing.T) {
+		cfg := generator.DefaultConfig
+		cfg.AfterId = 200
+		after := generateBooks(t, cfg, 100)
+		if after[0].Id <= 200 {
+			t.Fatalf("expecting ids after 200, got: %d", after[0].Id)
+		}
+		for i, b := range after {
+			if b.Id != books[i].Id+200 || b.Title != books[i].Title {
+				t.Fatalf("expecting the same rows moved by 200 ids, got %+v for %+v", b, books[i])
+			}
+		}
+	})
+
+	t.Run("timestamps have ties, late rows and stay in the span", func(t *testing.T) {
+		ties, late := 0, 0
+		end := generator.DefaultConfig.Start.Add(generator.DefaultConfig.Span)
+		for i, b := range books {
+			if b.CreatedAt.Before(generator.DefaultConfig.Start.Add(-time.Minute)) || b.CreatedAt.After(end) {
+				t.Fatalf("created_at out of the span: %v", b.CreatedAt)
+			}
+			if i == 0 {
+				continue
+			}
+			switch {
+			case b.CreatedAt.Equal(books[i-1].CreatedAt):
+				ties++
+			case b.CreatedAt.Before(books[i-1].CreatedAt):
+				late++
+			}
+		}
+		if ties < 300 || late < 50 {
+			t.Errorf("expecting ties and late rows, got %d ties and %d late rows", ties, late)
+		}
+
+		// the second half of the span holds three quarters of the rows
+		middle := generator.DefaultConfig.Start.Add(generator.DefaultConfig.Span / 2)
+		if books[len(books)/2].CreatedAt.Before(middle) {
+			t.Errorf("expecting rows to be denser towards the end of the span")
+		}
+	})
+
+	t.Run("a few authors write most books", func(t *testing.T) {
+		counts := make(map[string]int)
+		for _, b := range books {
+			counts[b.Author]++
+		}
+		most := 0
+		for _, count := range counts {
+			if count > most {
+				most = count
+			}
+		}
+		if most < 10*len(books)/len(counts) {
+			t.Errorf("expecting a skewed distribution of authors, the most common wrote %d of %d books by %d authors", most, len(books), len(counts))
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		deleted float64
+		ties    float64
+		valid   bool
+	}{
+		{name: "default shares", deleted: 0.02, ties: 0.05, valid: true},
+		{name: "no deleted ids", deleted: 0, ties: 0, valid: true},
+		{name: "every id deleted", deleted: 1, ties: 0.05},
+		{name: "more than every id deleted", deleted: 2, ties: 0.05},
+		{name: "negative share of deleted ids", deleted: -0.1, ties: 0.05},
+		{name: "share of ties above 1", deleted: 0.02, ties: 1.5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := generator.DefaultConfig
+			cfg.Deleted, cfg.Ties = tc.deleted, tc.ties
+			if err := cfg.Validate(); (err == nil) != tc.valid {
+				t.Errorf("unexpected validation result: %v", err)
+			}
+			// generating with an invalid config fails instead of looping forever
+			err := generator.Books(cfg, 10, func(booksModels.Book) error { return nil })
+			if (err == nil) != tc.valid {
+				t.Errorf("unexpected error generating books: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoader(t *testing.T) {
+	db, err := sql.Open("ramsql", "Test generator loader")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	// ramsql ignores explicit values of BIGSERIAL columns, so ids are plain integers here
+	if _, err := db.Exec(`CREATE TABLE cars (car_id INT PRIMARY KEY, brand VARCHAR ( 100 ) NOT NULL, model VARCHAR ( 100 ) NOT NULL, created_at TIMESTAMP NOT NULL);`); err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+
+	if err := generator.NewLoader(db, generator.Insert, 100).LoadCars(context.Background(), generator.DefaultConfig, 250); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	expected := make(map[int]carsModels.Car)
+	generator.Cars(generator.DefaultConfig, 250, func(c carsModels.Car) error {
+		expected[c.Id] = c
+		return nil
+	})
+	rows, err := db.Query("SELECT car_id, brand, model FROM cars;")
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	defer rows.Close()
+	count := 0
+	for rows.Next() {
+		var c carsModels.Car
+		if err := rows.Scan(&c.Id, &c.Brand, &c.Model); err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		if e := expected[c.Id]; e.Brand != c.Brand || e.Model != c.Model {
+			t.Errorf("unexpected car %d, got: %s %s, expected: %s %s", c.Id, c.Brand, c.Model, e.Brand, e.Model)
+		}
+		count++
+	}
+	if count != 250 {
+		t.Errorf("expecting 250 cars, got: %d", count)
+	}
+
+	last, err := generator.LastId(context.Background(), db, "cars", "car_id")
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	highest := 0
+	for id := range expected {
+		if id > highest {
+			highest = id
+		}
+	}
+	if last != highest {
+		t.Errorf("expecting last id %d, got: %d", highest, last)
+	}
+}
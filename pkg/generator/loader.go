@@ -0,0 +1,163 @@
+package generator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/lib/pq"
+	"strings"
+)
+
+type Method string
+
+const (
+	// Copy streams rows with COPY FROM STDIN, which only Postgres supports.
+	Copy Method = "copy"
+	// Insert sends multi-row INSERT statements of BatchSize rows.
+	Insert Method = "insert"
+)
+
+// Loader writes generated rows to a database in a single transaction, holding
+// at most one batch in memory.
+type Loader struct {
+	db        *sql.DB
+	method    Method
+	batchSize int
+}
+
+func NewLoader(db *sql.DB, method Method, batchSize int) *Loader {
+	return &Loader{db: db, method: method, batchSize: batchSize}
+}
+
+func (l Loader) LoadBooks(ctx context.Context, cfg Config, n int) error {
+	return l.load(ctx, "books", []string{"book_id", "title", "author", "created_at"}, func(row func(...any) error) error {
+		return Books(cfg, n, func(b booksModels.Book) error {
+			return row(b.Id, b.Title, b.Author, b.CreatedAt)
+		})
+	})
+}
+
+func (l Loader) LoadCars(ctx context.Context, cfg Config, n int) error {
+	return l.load(ctx, "cars", []string{"car_id", "brand", "model", "created_at"}, func(row func(...any) error) error {
+		return Cars(cfg, n, func(c carsModels.Car) error {
+			return row(c.Id, c.Brand, c.Model, c.CreatedAt)
+		})
+	})
+}
+
+// SyncSequences moves the Postgres sequences of the ids past the loaded rows,
+// which carry their own ids, so rows inserted later do not collide with them.
+func SyncSequences(ctx context.Context, db *sql.DB) error {
+	for table, key := range map[string]string{"books": "book_id", "cars": "car_id"} {
+		query := fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', '%s'), (SELECT COALESCE(MAX(%s), 0) + 1 FROM %s), false);", table, key, key, table)
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("error while moving the sequence of %s: %v", table, err)
+		}
+	}
+	return nil
+}
+
+// LastId returns the highest id of the table, 0 when it is empty.
+func LastId(ctx context.Context, db *sql.DB, table, key string) (int, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s ORDER BY %s DESC LIMIT 1;", key, table, key)
+	var id int
+	err := db.QueryRowContext(ctx, query).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error while reading the last id of %s: %v", table, err)
+	}
+	return id, nil
+}
+
+func (l Loader) load(ctx context.Context, table string, columns []string, generate func(row func(...any) error) error) error {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error while starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	switch l.method {
+	case Copy:
+		err = copyRows(ctx, tx, table, columns, generate)
+	case Insert:
+		err = insertRows(ctx, tx, table, columns, l.batchSize, generate)
+	default:
+		err = fmt.Errorf("unknown method: %s, expected one of: %s, %s", l.method, Copy, Insert)
+	}
+	if err != nil {
+		return fmt.Errorf("error while loading %s: %v", table, err)
+	}
+	return tx.Commit()
+}
+
+func copyRows(ctx context.Context, tx *sql.Tx, table string, columns []string, generate func(row func(...any) error) error) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	err = generate(func(values ...any) error {
+		_, err := stmt.ExecContext(ctx, values...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	// an Exec without values flushes the buffered rows
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
+func insertRows(ctx context.Context, tx *sql.Tx, table string, columns []string, batchSize int, generate func(row func(...any) error) error) error {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	var batch []any
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		_, err := tx.ExecContext(ctx, insertQuery(table, columns, len(batch)/len(columns)), batch...)
+		batch = batch[:0]
+		return err
+	}
+
+	err := generate(func(values ...any) error {
+		batch = append(batch, values...)
+		if len(batch) >= batchSize*len(columns) {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+func insertQuery(table string, columns []string, rows int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", "))
+	param := 1
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("(")
+		for j := range columns {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "$%d", param)
+			param++
+		}
+		b.WriteString(")")
+	}
+	b.WriteString(";")
+	return b.String()
+}
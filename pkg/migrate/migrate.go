@@ -0,0 +1,262 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var files embed.FS
+
+const createTable = "CREATE TABLE IF NOT EXISTS schema_migrations (version INT PRIMARY KEY, name VARCHAR ( 100 ) NOT NULL, applied_at TIMESTAMP NOT NULL);"
+
+// Migration changes the schema from Version-1 to Version. Up and Down hold
+// statements separated by semicolons, the ones within $$ quoted function bodies
+// do not separate statements.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies migrations in order of their versions and records the
+// applied ones in the schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	prepared   bool
+}
+
+// New returns a migrator with the migrations embedded from the migrations
+// directory, named <version>_<name>.up.sql and <version>_<name>.down.sql.
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up applies every pending migration and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.UpTo(ctx, 0)
+}
+
+// UpTo applies the pending migrations up to and including version, or every
+// pending migration when version is 0, and returns the ones it applied.
+func (m *Migrator) UpTo(ctx context.Context, version int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Migration
+	for _, migration := range m.migrations {
+		if version > 0 && migration.Version > version {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := m.run(ctx, migration.Up, "INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3);", migration.Version, migration.Name, time.Now().UTC().Truncate(time.Microsecond))
+		if err != nil {
+			return result, fmt.Errorf("error while applying migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+		result = append(result, migration)
+	}
+	return result, nil
+}
+
+// Down reverts the most recently applied migration. It reports false when no
+// migration is applied.
+func (m *Migrator) Down(ctx context.Context) (Migration, bool, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return Migration{}, false, err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		err := m.run(ctx, migration.Down, "DELETE FROM schema_migrations WHERE version = $1;", migration.Version)
+		if err != nil {
+			return migration, false, fmt.Errorf("error while reverting migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+		return migration, true, nil
+	}
+	return Migration{}, false, nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Status
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		result = append(result, Status{Migration: migration, Applied: ok, AppliedAt: appliedAt})
+	}
+	return result, nil
+}
+
+// Script writes every migration as a single SQL script, each of them applied
+// and recorded in schema_migrations within its own transaction, so a database
+// initialized from the script is seen as migrated.
+func (m *Migrator) Script(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, createTable); err != nil {
+		return err
+	}
+	for _, migration := range m.migrations {
+		_, err := fmt.Fprintf(w, "\n-- %04d_%s\nBEGIN;\n%s\nINSERT INTO schema_migrations (version, name, applied_at) VALUES (%d, '%s', now() at time zone 'utc');\nCOMMIT;\n",
+			migration.Version, migration.Name, strings.TrimSpace(migration.Up), migration.Version, strings.ReplaceAll(migration.Name, "'", "''"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run executes the statements of a migration and the bookkeeping query in a
+// single transaction.
+func (m *Migrator) run(ctx context.Context, statements, record string, args ...any) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range split(statements) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// split splits statements at the semicolons outside of $$ quotes.
+func split(statements string) []string {
+	var result []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(statements); i++ {
+		switch {
+		case strings.HasPrefix(statements[i:], "$$"):
+			quoted = !quoted
+			i++
+		case statements[i] == ';' && !quoted:
+			if statement := strings.TrimSpace(statements[start:i]); statement != "" {
+				result = append(result, statement+";")
+			}
+			start = i + 1
+		}
+	}
+	if statement := strings.TrimSpace(statements[start:]); statement != "" {
+		result = append(result, statement+";")
+	}
+	return result
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, fmt.Errorf("error while creating schema_migrations: %v", err)
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations;")
+	if err != nil {
+		return nil, fmt.Errorf("error while reading schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("error while reading schema_migrations: %v", err)
+		}
+		applied[version] = appliedAt.UTC()
+	}
+	return applied, rows.Err()
+}
+
+// createTable creates schema_migrations unless it exists. It runs once per
+// migrator, because ramsql empties existing tables on CREATE TABLE IF NOT
+// EXISTS and fails to recover from queries of tables which do not exist.
+func (m *Migrator) createTable(ctx context.Context) error {
+	if m.prepared {
+		return nil
+	}
+	_, err := m.db.ExecContext(ctx, createTable)
+	m.prepared = err == nil
+	return err
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, path := range names {
+		name := strings.TrimPrefix(path, "migrations/")
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s is not named <version>_<name>.up.sql or .down.sql", name)
+		}
+		prefix, title, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s does not start with a version: %v", name, err)
+		}
+
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: title}
+			byVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var migrations []Migration
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
@@ -0,0 +1,117 @@
+package migrate_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/migrate"
+	_ "github.com/proullon/ramsql/driver"
+	"strings"
+	"testing"
+)
+
+func TestMigrator(t *testing.T) {
+	db, err := sql.Open("ramsql", "Test migrations")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	ctx := context.Background()
+
+	// later migrations create indexes and triggers, which ramsql does not support
+	assertApplied := func(expected bool) {
+		t.Helper()
+		status, err := m.Status(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		if len(status) == 0 {
+			t.Fatalf("expecting embedded migrations")
+		}
+		for _, s := range status {
+			if s.Version > 1 {
+				if s.Applied {
+					t.Errorf("expecting migration %d_%s to be pending", s.Version, s.Name)
+				}
+				continue
+			}
+			if s.Applied != expected {
+				t.Errorf("expecting migration %d_%s to be applied: %v", s.Version, s.Name, expected)
+			}
+		}
+	}
+
+	assertApplied(false)
+
+	applied, err := m.UpTo(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	if len(applied) != 1 || applied[0].Version != 1 {
+		t.Errorf("expecting the first migration to be applied, got: %+v", applied)
+	}
+	if _, err := db.Exec("INSERT INTO books (title, author, created_at) VALUES ('title', 'author', '2023-03-23 19:00:00');"); err != nil {
+		t.Errorf("expecting books table to be created, got: %v", err)
+	}
+	assertApplied(true)
+
+	if applied, err := m.UpTo(ctx, 1); err != nil || len(applied) != 0 {
+		t.Errorf("expecting nothing to apply twice, got: %+v and %v", applied, err)
+	}
+
+	for {
+		_, reverted, err := m.Down(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		if !reverted {
+			break
+		}
+	}
+	assertApplied(false)
+	if _, err := db.Exec("INSERT INTO books (title, author, created_at) VALUES ('title', 'author', '2023-03-23 19:00:00');"); err == nil {
+		t.Errorf("expecting books table to be dropped")
+	}
+}
+
+func TestScript(t *testing.T) {
+	db, err := sql.Open("ramsql", "Test migration script")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	var script strings.Builder
+	if err := m.Script(&script); err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+	status, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error occured: %v", err)
+	}
+
+	// every migration is applied in order and recorded right after it
+	position := 0
+	for _, s := range status {
+		up := strings.Index(script.String()[position:], strings.TrimSpace(s.Up))
+		if up < 0 {
+			t.Fatalf("expecting migration %d_%s in the script after position %d", s.Version, s.Name, position)
+		}
+		position += up
+		record := fmt.Sprintf("VALUES (%d, '%s',", s.Version, s.Name)
+		recorded := strings.Index(script.String()[position:], record)
+		if recorded < 0 {
+			t.Fatalf("expecting migration %d_%s to be recorded after it is applied", s.Version, s.Name)
+		}
+		position += recorded
+	}
+}
@@ -0,0 +1,5 @@
+drop table api_keys;
+
+drop table cars;
+
+drop table books;
@@ -5,11 +5,6 @@ create table if not exists books (
     created_at TIMESTAMP NOT NULL
 );
 
-CREATE SEQUENCE books_sequence
-    start 1
-  increment 1;
-
-
 create table if not exists cars (
     car_id serial PRIMARY KEY,
     brand VARCHAR ( 100 ) NOT NULL,
@@ -17,6 +12,8 @@ create table if not exists cars (
     created_at TIMESTAMP NOT NULL
 );
 
-CREATE SEQUENCE cars_sequence
-    start 1
-  increment 1;
\ No newline at end of file
+create table if not exists api_keys (
+    key_id VARCHAR ( 100 ) PRIMARY KEY,
+    key_hash CHAR ( 64 ) NOT NULL UNIQUE,
+    scopes VARCHAR ( 500 ) NOT NULL
+);
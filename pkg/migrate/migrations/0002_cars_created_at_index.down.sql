@@ -0,0 +1 @@
+drop index if exists cars_created_at_car_id_idx;
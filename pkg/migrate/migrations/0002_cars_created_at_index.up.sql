@@ -0,0 +1 @@
+create index if not exists cars_created_at_car_id_idx on cars (created_at, car_id);
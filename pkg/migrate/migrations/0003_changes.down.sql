@@ -0,0 +1,17 @@
+drop trigger if exists cars_changes on cars;
+
+drop trigger if exists books_changes on books;
+
+drop trigger if exists cars_updated_at on cars;
+
+drop trigger if exists books_updated_at on books;
+
+drop function if exists record_change();
+
+drop function if exists touch_updated_at();
+
+drop table if exists changes;
+
+alter table cars drop column if exists updated_at;
+
+alter table books drop column if exists updated_at;
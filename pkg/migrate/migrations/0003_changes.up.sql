@@ -0,0 +1,49 @@
+alter table books add column if not exists updated_at TIMESTAMP NOT NULL DEFAULT (now() at time zone 'utc');
+
+alter table cars add column if not exists updated_at TIMESTAMP NOT NULL DEFAULT (now() at time zone 'utc');
+
+-- every write of books and cars, deletes are kept as tombstones
+create table if not exists changes (
+    change_id bigserial PRIMARY KEY,
+    resource VARCHAR ( 20 ) NOT NULL,
+    item_id INT NOT NULL,
+    operation VARCHAR ( 10 ) NOT NULL,
+    txid BIGINT NOT NULL DEFAULT (pg_current_xact_id()::text::bigint),
+    changed_at TIMESTAMP NOT NULL DEFAULT (now() at time zone 'utc')
+);
+
+create index if not exists changes_resource_txid_change_id_idx on changes (resource, txid, change_id);
+
+create or replace function touch_updated_at() returns trigger as $$
+begin
+    NEW.updated_at := now() at time zone 'utc';
+    return NEW;
+end;
+$$ language plpgsql;
+
+-- record_change takes the name of the key column of the table
+create or replace function record_change() returns trigger as $$
+declare
+    item jsonb;
+begin
+    if TG_OP = 'DELETE' then
+        item := to_jsonb(OLD);
+    else
+        item := to_jsonb(NEW);
+    end if;
+    insert into changes (resource, item_id, operation) values (TG_TABLE_NAME, (item ->> TG_ARGV[0])::int, lower(TG_OP));
+    return null;
+end;
+$$ language plpgsql;
+
+drop trigger if exists books_updated_at on books;
+create trigger books_updated_at before update on books for each row execute function touch_updated_at();
+
+drop trigger if exists cars_updated_at on cars;
+create trigger cars_updated_at before update on cars for each row execute function touch_updated_at();
+
+drop trigger if exists books_changes on books;
+create trigger books_changes after insert or update or delete on books for each row execute function record_change('book_id');
+
+drop trigger if exists cars_changes on cars;
+create trigger cars_changes after insert or update or delete on cars for each row execute function record_change('car_id');
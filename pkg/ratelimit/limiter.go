@@ -0,0 +1,203 @@
+package ratelimit
+
+import (
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/auth"
+	"github.com/krukkrz/pagination/pkg/problem"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limit describes a token bucket: it refills with Rate tokens per second up to
+// Burst tokens.
+type Limit struct {
+	Rate  float64
+	Burst float64
+}
+
+// Policy limits a client on a route twice: every request takes one token from
+// the Requests bucket and Cost tokens from the Cost bucket.
+type Policy struct {
+	Requests Limit
+	Cost     Limit
+}
+
+type Config struct {
+	Default Policy
+	Routes  map[string]Policy
+	// Cost tells how expensive a request is, DefaultCost is used when nil.
+	// Requests it returns an error for are rejected without being charged.
+	Cost func(r *http.Request) (float64, error)
+}
+
+type Limiter struct {
+	config Config
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+}
+
+type bucketKey struct {
+	client string
+	route  string
+	cost   bool
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+func New(config Config) *Limiter {
+	if config.Cost == nil {
+		config.Cost = DefaultCost
+	}
+	return &Limiter{
+		config:  config,
+		now:     time.Now,
+		buckets: make(map[bucketKey]*bucket),
+	}
+}
+
+// DefaultCost charges one token per request, one more for every 1000 skipped
+// rows and one more for every 100 requested rows, whether they are asked for
+// with limit or as the n rows of a scan. A numbered page skips up to 999 rows
+// from its checkpoint, which costs one more token. Negative values are an
+// error, they would lower the price.
+func DefaultCost(r *http.Request) (float64, error) {
+	query := r.URL.Query()
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	n, _ := strconv.Atoi(query.Get("n"))
+	if offset < 0 || limit < 0 || n < 0 {
+		return 0, fmt.Errorf("limit, offset and n must not be negative")
+	}
+	price := 1 + math.Floor(float64(offset)/1000) + math.Floor(float64(limit+n)/100)
+	if query.Has("page") {
+		price++
+	}
+	return price, nil
+}
+
+func (l *Limiter) Middleware(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		policy, ok := l.config.Routes[route]
+		if !ok {
+			policy = l.config.Default
+		}
+		client := ClientKey(r)
+		price, err := l.config.Cost(r)
+		if err != nil {
+			problem.Write(rw, http.StatusBadRequest, err.Error())
+			return
+		}
+		// every request costs at least one token, and one costing more than the
+		// whole budget could never be served
+		price = math.Max(1, price)
+		if price > policy.Cost.Burst {
+			problem.Write(rw, http.StatusBadRequest, fmt.Sprintf("request costs %s tokens, more than the budget of %s, ask for fewer rows", formatFloat(price), formatFloat(policy.Cost.Burst)))
+			return
+		}
+
+		l.mu.Lock()
+		now := l.now()
+		l.sweep(now)
+		requests := l.bucket(bucketKey{client: client, route: route}, policy.Requests, now)
+		cost := l.bucket(bucketKey{client: client, route: route, cost: true}, policy.Cost, now)
+		requestsWait := requests.wait(1, policy.Requests)
+		costWait := cost.wait(price, policy.Cost)
+		allowed := requestsWait == 0 && costWait == 0
+		if allowed {
+			requests.tokens--
+			cost.tokens -= price
+		}
+		remaining := math.Floor(requests.tokens)
+		reset := requests.wait(policy.Requests.Burst, policy.Requests)
+		l.mu.Unlock()
+
+		rw.Header().Set("RateLimit-Limit", formatFloat(policy.Requests.Burst))
+		rw.Header().Set("RateLimit-Remaining", formatFloat(remaining))
+		rw.Header().Set("RateLimit-Reset", seconds(reset))
+		if !allowed {
+			retryAfter := requestsWait
+			if costWait > retryAfter {
+				retryAfter = costWait
+			}
+			rw.Header().Set("Retry-After", seconds(retryAfter))
+			problem.Write(rw, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// bucket returns the refilled bucket of the key, it must be called with mu held.
+func (l *Limiter) bucket(key bucketKey, limit Limit, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit.Burst, updatedAt: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(limit.Burst, b.tokens+now.Sub(b.updatedAt).Seconds()*limit.Rate)
+	b.updatedAt = now
+	return b
+}
+
+// sweep forgets buckets which had time to refill completely, it must be called
+// with mu held.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.updatedAt) > 10*time.Minute {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// wait tells how long it takes until the bucket holds the tokens.
+func (b *bucket) wait(tokens float64, limit Limit) time.Duration {
+	missing := tokens - b.tokens
+	if missing <= 0 {
+		return 0
+	}
+	if limit.Rate <= 0 || tokens > limit.Burst {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(missing / limit.Rate * float64(time.Second))
+}
+
+// ClientKey identifies the client by the id of its API key once it was
+// authenticated, or by its IP address otherwise. Keys are never taken from the
+// request unchecked, a client sending a new one with every request would get
+// a new bucket every time.
+func ClientKey(r *http.Request) string {
+	if key, ok := auth.FromContext(r.Context()); ok {
+		return "key:" + key.Id
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func seconds(d time.Duration) string {
+	if d == time.Duration(math.MaxInt64) {
+		return "3600"
+	}
+	return fmt.Sprintf("%d", int64(math.Ceil(d.Seconds())))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
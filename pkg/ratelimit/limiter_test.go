@@ -0,0 +1,170 @@
+package ratelimit_test
+
+import (
+	"github.com/krukkrz/pagination/pkg/auth"
+	"github.com/krukkrz/pagination/pkg/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLimiter(t *testing.T) {
+	ok := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {})
+
+	type request struct {
+		url            string
+		remoteAddr     string
+		keyId          string
+		header         string
+		expectedStatus int
+	}
+
+	testCases := []struct {
+		name               string
+		config             ratelimit.Config
+		route              string
+		requests           []request
+		expectedRetryAfter string
+	}{
+		{
+			name: "rejects requests above the burst",
+			config: ratelimit.Config{Default: ratelimit.Policy{
+				Requests: ratelimit.Limit{Rate: 1, Burst: 2},
+				Cost:     ratelimit.Limit{Rate: 100, Burst: 100},
+			}},
+			route: "books",
+			requests: []request{
+				{url: "/books?limit=10&offset=0", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusOK},
+				{url: "/books?limit=10&offset=0", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusOK},
+				{url: "/books?limit=10&offset=0", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusTooManyRequests},
+			},
+			expectedRetryAfter: "1",
+		},
+		{
+			name: "limits every client separately",
+			config: ratelimit.Config{Default: ratelimit.Policy{
+				Requests: ratelimit.Limit{Rate: 1, Burst: 1},
+				Cost:     ratelimit.Limit{Rate: 100, Burst: 100},
+			}},
+			route: "books",
+			requests: []request{
+				{url: "/books?limit=10&offset=0", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusOK},
+				{url: "/books?limit=10&offset=0", remoteAddr: "10.0.0.2:1234", expectedStatus: http.StatusOK},
+				{url: "/books?limit=10&offset=0", remoteAddr: "10.0.0.1:1234", keyId: "mobile", expectedStatus: http.StatusOK},
+				{url: "/books?limit=10&offset=0", remoteAddr: "10.0.0.3:1234", keyId: "mobile", expectedStatus: http.StatusTooManyRequests},
+			},
+			expectedRetryAfter: "1",
+		},
+		{
+			name: "deep offsets consume the cost budget",
+			config: ratelimit.Config{Default: ratelimit.Policy{
+				Requests: ratelimit.Limit{Rate: 100, Burst: 100},
+				Cost:     ratelimit.Limit{Rate: 2, Burst: 10},
+			}},
+			route: "books",
+			requests: []request{
+				{url: "/books?limit=100&offset=5000", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusOK},
+				{url: "/books?limit=10&offset=0", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusOK},
+				{url: "/books?limit=100&offset=5000", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusTooManyRequests},
+			},
+			expectedRetryAfter: "3",
+		},
+		{
+			name: "limits unchecked keys by address",
+			config: ratelimit.Config{Default: ratelimit.Policy{
+				Requests: ratelimit.Limit{Rate: 1, Burst: 1},
+				Cost:     ratelimit.Limit{Rate: 100, Burst: 100},
+			}},
+			route: "books",
+			requests: []request{
+				{url: "/books?limit=10&offset=0", remoteAddr: "10.0.0.1:1234", header: "Bearer first", expectedStatus: http.StatusOK},
+				{url: "/books?limit=10&offset=0", remoteAddr: "10.0.0.1:1234", header: "Bearer second", expectedStatus: http.StatusTooManyRequests},
+			},
+			expectedRetryAfter: "1",
+		},
+		{
+			name: "rejects negative limits and offsets without charging them",
+			config: ratelimit.Config{Default: ratelimit.Policy{
+				Requests: ratelimit.Limit{Rate: 1, Burst: 1},
+				Cost:     ratelimit.Limit{Rate: 1, Burst: 1},
+			}},
+			route: "books",
+			requests: []request{
+				{url: "/books?limit=-1000&offset=0", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusBadRequest},
+				{url: "/books?limit=10&offset=-5000", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusBadRequest},
+				{url: "/books?limit=10&offset=0", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusOK},
+			},
+		},
+		{
+			name: "rejects requests costing more than the budget",
+			config: ratelimit.Config{Default: ratelimit.Policy{
+				Requests: ratelimit.Limit{Rate: 100, Burst: 100},
+				Cost:     ratelimit.Limit{Rate: 2, Burst: 10},
+			}},
+			route: "books",
+			requests: []request{
+				{url: "/books?limit=10000000&offset=0", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusBadRequest},
+				{url: "/books?limit=10&offset=20000", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusBadRequest},
+				{url: "/cars/scans/id?n=5000", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusBadRequest},
+				{url: "/books?limit=900&offset=0", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusOK},
+				{url: "/cars?page=2&limit=800", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusTooManyRequests},
+			},
+			expectedRetryAfter: "5",
+		},
+		{
+			name: "uses the policy of the route",
+			config: ratelimit.Config{
+				Default: ratelimit.Policy{
+					Requests: ratelimit.Limit{Rate: 100, Burst: 100},
+					Cost:     ratelimit.Limit{Rate: 100, Burst: 100},
+				},
+				Routes: map[string]ratelimit.Policy{
+					"cars": {
+						Requests: ratelimit.Limit{Rate: 0.5, Burst: 1},
+						Cost:     ratelimit.Limit{Rate: 100, Burst: 100},
+					},
+				},
+			},
+			route: "cars",
+			requests: []request{
+				{url: "/cars?cursor=1&limit=10", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusOK},
+				{url: "/cars?cursor=1&limit=10", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusTooManyRequests},
+			},
+			expectedRetryAfter: "2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := ratelimit.New(tc.config).Middleware(tc.route, ok)
+
+			var rr *httptest.ResponseRecorder
+			for i, r := range tc.requests {
+				req := httptest.NewRequest("GET", r.url, nil)
+				req.RemoteAddr = r.remoteAddr
+				if r.header != "" {
+					req.Header.Set("Authorization", r.header)
+				}
+				if r.keyId != "" {
+					req = req.WithContext(auth.NewContext(req.Context(), auth.Key{Id: r.keyId}))
+				}
+				rr = httptest.NewRecorder()
+				handler.ServeHTTP(rr, req)
+
+				if rr.Code != r.expectedStatus {
+					t.Fatalf("request %d returned wrong status code: got %v want %v", i, rr.Code, r.expectedStatus)
+				}
+				if rr.Code == http.StatusBadRequest {
+					continue
+				}
+				if rr.Header().Get("RateLimit-Limit") == "" || rr.Header().Get("RateLimit-Remaining") == "" || rr.Header().Get("RateLimit-Reset") == "" {
+					t.Errorf("request %d is missing RateLimit headers: %v", i, rr.Header())
+				}
+			}
+
+			if actual := rr.Header().Get("Retry-After"); actual != tc.expectedRetryAfter {
+				t.Errorf("unexpected Retry-After, got: %s, expected: %s", actual, tc.expectedRetryAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	Insert = "insert"
+	Update = "update"
+	Delete = "delete"
+)
+
+// ChangePosition is the place of a change in the feed: changes are ordered by
+// the id of the transaction writing them and then by their own id.
+type ChangePosition struct {
+	Txid int64
+	Id   int64
+}
+
+// Change is a write of an item recorded in the changes table. Item holds the
+// current state of the item, it is nil once the item is deleted, which makes
+// the change a tombstone.
+type Change[T any] struct {
+	Position  ChangePosition `json:"-"`
+	Operation string         `json:"operation"`
+	Key       int            `json:"id"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	Item      *T             `json:"item"`
+}
+
+// defaultHorizon is the oldest transaction which may still be running. Every
+// transaction writing changes before it has ended, so no change below it can
+// show up later.
+const defaultHorizon = "SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint;"
+
+// SetHorizon sets the query returning the oldest transaction which may still
+// be running. An empty query treats every recorded change as final, which is
+// only right for databases without concurrent writers.
+func (r *Repository[T]) SetHorizon(query string) {
+	r.horizon = query
+}
+
+// FetchChanges returns limit changes of the resource after the position,
+// together with the current state of the changed items. Changes of
+// transactions which may still be running are left for later, so a change
+// committed out of order is never skipped.
+func (r Repository[T]) FetchChanges(ctx context.Context, resource string, after ChangePosition, limit int) ([]Change[T], error) {
+	tx, err := r.db.BeginTx(ctx, r.txOptions)
+	if err != nil {
+		return nil, fmt.Errorf("error while starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	conditions, args, err := r.feedConditions(ctx, tx, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	// the rest of the transaction of the position first, then the later ones
+	changes, err := r.changes(ctx, tx, conditions, args, limit, condition{"txid = $%d", after.Txid}, condition{"change_id > $%d", after.Id})
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) < limit {
+		later, err := r.changes(ctx, tx, conditions, args, limit-len(changes), condition{"txid > $%d", after.Txid})
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, later...)
+	}
+
+	if err := r.attach(ctx, tx, changes); err != nil {
+		return nil, err
+	}
+	return changes, tx.Commit()
+}
+
+// FetchLatestChange returns the position of the last final change of the
+// resource, reading the feed after it skips every change recorded so far. It
+// returns the zero position when there is no change.
+func (r Repository[T]) FetchLatestChange(ctx context.Context, resource string) (ChangePosition, error) {
+	tx, err := r.db.BeginTx(ctx, r.txOptions)
+	if err != nil {
+		return ChangePosition{}, fmt.Errorf("error while starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	conditions, args, err := r.feedConditions(ctx, tx, resource)
+	if err != nil {
+		return ChangePosition{}, err
+	}
+	query := fmt.Sprintf("SELECT txid, change_id FROM changes WHERE %s ORDER BY txid DESC, change_id DESC LIMIT 1;", strings.Join(conditions, " AND "))
+
+	var position ChangePosition
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&position.Txid, &position.Id)
+	if err != nil && err != sql.ErrNoRows {
+		return ChangePosition{}, fmt.Errorf("error occured while running query: %s, error: %v", query, err)
+	}
+	return position, tx.Commit()
+}
+
+// feedConditions limits the changes table to the final changes of the
+// resource, the ones below the horizon.
+func (r Repository[T]) feedConditions(ctx context.Context, tx *sql.Tx, resource string) ([]string, []any, error) {
+	var conditions []string
+	var args []any
+	where := func(format string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+	where("resource = $%d", resource)
+	if r.horizon != "" {
+		var horizon int64
+		if err := tx.QueryRowContext(ctx, r.horizon).Scan(&horizon); err != nil {
+			return nil, nil, fmt.Errorf("error occured while running query: %s, error: %v", r.horizon, err)
+		}
+		where("txid < $%d", horizon)
+	}
+	return conditions, args, nil
+}
+
+func (r Repository[T]) changes(ctx context.Context, tx *sql.Tx, conditions []string, args []any, limit int, extra ...condition) ([]Change[T], error) {
+	conditions = append([]string(nil), conditions...)
+	args = append([]any(nil), args...)
+	for _, c := range extra {
+		args = append(args, c.value)
+		conditions = append(conditions, fmt.Sprintf(c.format, len(args)))
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf("SELECT txid, change_id, operation, item_id, changed_at FROM changes WHERE %s ORDER BY txid, change_id LIMIT $%d;", strings.Join(conditions, " AND "), len(args))
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error occured while running query: %s, error: %v", query, err)
+	}
+	defer rows.Close()
+
+	var changes []Change[T]
+	for rows.Next() {
+		var c Change[T]
+		if err := rows.Scan(&c.Position.Txid, &c.Position.Id, &c.Operation, &c.Key, &c.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error while parsing rows: %v", err)
+		}
+		c.UpdatedAt = c.UpdatedAt.UTC()
+		changes = append(changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while parsing rows: %v", err)
+	}
+	return changes, nil
+}
+
+// attach reads the current state of the changed items, items which are gone
+// are left nil.
+func (r Repository[T]) attach(ctx context.Context, tx *sql.Tx, changes []Change[T]) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	seen := make(map[int]bool)
+	var keys []any
+	var placeholders []string
+	for _, c := range changes {
+		if seen[c.Key] {
+			continue
+		}
+		seen[c.Key] = true
+		keys = append(keys, c.Key)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(keys)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s IN (%s);", r.columnList(), r.table, r.key, strings.Join(placeholders, ", "))
+	items, err := r.query(ctx, tx, query, keys...)
+	if err != nil {
+		return err
+	}
+	byKey := make(map[int]*T, len(items))
+	for i := range items {
+		byKey[r.keyOf(items[i])] = &items[i]
+	}
+	for i := range changes {
+		changes[i].Item = byKey[changes[i].Key]
+	}
+	return nil
+}
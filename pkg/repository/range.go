@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Range selects the rows whose time lies between Since and Until. A zero bound
+// leaves that side open. Since is included and Until excluded unless told
+// otherwise, so adjacent ranges never share a row.
+type Range struct {
+	Since          time.Time
+	Until          time.Time
+	SinceExclusive bool
+	UntilInclusive bool
+}
+
+// Contains tells whether the time lies within the range.
+func (r Range) Contains(t time.Time) bool {
+	if !r.Since.IsZero() && (t.Before(r.Since) || r.SinceExclusive && t.Equal(r.Since)) {
+		return false
+	}
+	if !r.Until.IsZero() && (t.After(r.Until) || !r.UntilInclusive && t.Equal(r.Until)) {
+		return false
+	}
+	return true
+}
+
+// Position is the place of a row ordered by time, with the key breaking ties
+// between rows of the same time.
+type Position struct {
+	Time time.Time
+	Key  int
+}
+
+// Less tells whether p comes before q.
+func (p Position) Less(q Position) bool {
+	return p.Time.Before(q.Time) || p.Time.Equal(q.Time) && p.Key < q.Key
+}
+
+// FetchRange returns limit rows whose column lies within the range, ordered by
+// the column and the key. When after is set it continues with the rows after
+// that position: first the rows left in its tie, then the later ones. Each of
+// the two queries is a plain range over an index of the column and the key.
+func (r Repository[T]) FetchRange(ctx context.Context, column string, rng Range, after *Position, limit int) ([]T, error) {
+	if _, ok := r.fields[column]; !ok {
+		return nil, fmt.Errorf("column %s of table %s is not mapped by any field", column, r.table)
+	}
+	if after == nil {
+		query, args := r.rangeQuery(column, rng, limit)
+		return r.query(ctx, r.db, query, args...)
+	}
+
+	query, args := r.rangeQuery(column, rng, limit,
+		condition{fmt.Sprintf("%s = $%%d", column), after.Time},
+		condition{fmt.Sprintf("%s > $%%d", r.key), after.Key})
+	tie, err := r.query(ctx, r.db, query, args...)
+	if err != nil || len(tie) == limit {
+		return tie, err
+	}
+	query, args = r.rangeQuery(column, rng, limit-len(tie), condition{fmt.Sprintf("%s > $%%d", column), after.Time})
+	later, err := r.query(ctx, r.db, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return append(tie, later...), nil
+}
+
+// condition compares a column with a value, format holds a single %d for the
+// number of the placeholder.
+type condition struct {
+	format string
+	value  any
+}
+
+// rangeQuery selects limit rows within the range and the extra conditions.
+func (r Repository[T]) rangeQuery(column string, rng Range, limit int, extra ...condition) (string, []any) {
+	var conditions []string
+	var args []any
+	where := func(format string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+	if !rng.Since.IsZero() {
+		operator := ">="
+		if rng.SinceExclusive {
+			operator = ">"
+		}
+		where(fmt.Sprintf("%s %s $%%d", column, operator), rng.Since)
+	}
+	if !rng.Until.IsZero() {
+		operator := "<"
+		if rng.UntilInclusive {
+			operator = "<="
+		}
+		where(fmt.Sprintf("%s %s $%%d", column, operator), rng.Until)
+	}
+	for _, c := range extra {
+		where(c.format, c.value)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", r.columnList(), r.table)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+	return query + fmt.Sprintf(" ORDER BY %s, %s LIMIT $%d;", column, r.key, len(args)), args
+}
@@ -0,0 +1,343 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Repository reads rows of a single table into T. Columns are mapped to the
+// fields of T by their `db` struct tags, so the order of columns in the table
+// does not matter.
+type Repository[T any] struct {
+	db        *sql.DB
+	table     string
+	key       string
+	columns   []string
+	fields    map[string]int
+	txOptions *sql.TxOptions
+	horizon   string
+}
+
+// snapshot makes every query of a transaction see the same data.
+var snapshot = sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+
+func New[T any](db *sql.DB, table, key string) *Repository[T] {
+	columns, fields := mapFields(reflect.TypeOf((*T)(nil)).Elem())
+	if _, ok := fields[key]; !ok {
+		panic(fmt.Sprintf("repository: key column %s is not mapped by any field of %T", key, *new(T)))
+	}
+	return &Repository[T]{
+		db:        db,
+		table:     table,
+		key:       key,
+		columns:   columns,
+		fields:    fields,
+		txOptions: &snapshot,
+		horizon:   defaultHorizon,
+	}
+}
+
+// SetTxOptions sets the options of transactions spanning several queries,
+// which are read-only snapshots by default. Drivers which support neither
+// isolation levels nor read-only transactions need nil.
+func (r *Repository[T]) SetTxOptions(opts *sql.TxOptions) {
+	r.txOptions = opts
+}
+
+func (r Repository[T]) FetchOffset(ctx context.Context, limit, offset int) ([]T, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s ORDER BY %s LIMIT $1 OFFSET $2;", r.columnList(), r.table, r.key)
+	return r.query(ctx, r.db, query, limit, offset)
+}
+
+// FetchOffsetAsOf pages through the rows whose column is at most asOf, which
+// hides the rows created later. Deleted rows still shift the offsets and
+// updated rows are read as they are now.
+func (r Repository[T]) FetchOffsetAsOf(ctx context.Context, column string, asOf time.Time, limit, offset int) ([]T, error) {
+	if _, ok := r.fields[column]; !ok {
+		return nil, fmt.Errorf("column %s of table %s is not mapped by any field", column, r.table)
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s <= $1 ORDER BY %s LIMIT $2 OFFSET $3;", r.columnList(), r.table, column, r.key)
+	return r.query(ctx, r.db, query, asOf, limit, offset)
+}
+
+// FetchOffsetDeferred returns the same rows as FetchOffset, but the offset is
+// skipped over the keys alone, which the primary key index answers without
+// reading the rows. Only the rows of the page are joined to the keys.
+func (r Repository[T]) FetchOffsetDeferred(ctx context.Context, limit, offset int) ([]T, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s JOIN (SELECT %s FROM %s ORDER BY %s LIMIT $1 OFFSET $2) AS page USING (%s) ORDER BY %s;",
+		r.columnList(), r.table, r.key, r.table, r.key, r.key, r.key)
+	return r.query(ctx, r.db, query, limit, offset)
+}
+
+func (r Repository[T]) FetchCursor(ctx context.Context, cursor, limit int) ([]T, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s >= $1 ORDER BY %s LIMIT $2;", r.columnList(), r.table, r.key, r.key)
+	return r.query(ctx, r.db, query, cursor, limit)
+}
+
+// FetchBefore returns limit rows with a key lower than cursor, the closest one
+// first.
+func (r Repository[T]) FetchBefore(ctx context.Context, cursor, limit int) ([]T, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s < $1 ORDER BY %s DESC LIMIT $2;", r.columnList(), r.table, r.key, r.key)
+	return r.query(ctx, r.db, query, cursor, limit)
+}
+
+// CountBefore returns the number of rows with a key lower than cursor.
+func (r Repository[T]) CountBefore(ctx context.Context, cursor int) (int, error) {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s < $1;", r.table, r.key)
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, cursor).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error occured while running query: %s, error: %v", query, err)
+	}
+	return count, nil
+}
+
+// FetchSeek returns limit rows starting skip rows after the row with key from,
+// or the first row with a greater key when it is gone.
+func (r Repository[T]) FetchSeek(ctx context.Context, from, skip, limit int) ([]T, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s >= $1 ORDER BY %s LIMIT $2 OFFSET $3;", r.columnList(), r.table, r.key, r.key)
+	return r.query(ctx, r.db, query, from, limit, skip)
+}
+
+// Checkpoints returns the key of every nth row in key order, starting with the
+// first row.
+func (r Repository[T]) Checkpoints(ctx context.Context, every int) ([]int, error) {
+	query := fmt.Sprintf("SELECT %s FROM (SELECT %s, row_number() OVER (ORDER BY %s) AS position FROM %s) AS numbered WHERE (position - 1) %% $1 = 0 ORDER BY %s;", r.key, r.key, r.key, r.table, r.key)
+	keys, err := r.keys(ctx, r.db, query, every)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := make([]int, 0, len(keys))
+	for _, key := range keys {
+		checkpoints = append(checkpoints, key.(int))
+	}
+	return checkpoints, nil
+}
+
+// DefaultBatchSize is the number of rows read by a single query of Iterate when
+// IterateOptions leave it unset.
+const DefaultBatchSize = 100
+
+type IterateOptions struct {
+	// BatchSize is the number of rows read by a single query.
+	BatchSize int
+	// After skips the rows with a key lower than or equal to it, so an
+	// interrupted iteration can resume from the last key it handled.
+	After int
+}
+
+// Iterate calls fn with every row after opts.After in key order, reading them
+// in batches by key. Rows inserted behind the last key while iterating are
+// picked up and rows deleted ahead of it are skipped, but no row is visited
+// twice. It stops at the first error returned by fn, or with the context error
+// as soon as ctx is done.
+func (r Repository[T]) Iterate(ctx context.Context, opts IterateOptions, fn func(T) error) error {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	return r.walk(ctx, r.db, opts.After, opts.BatchSize, fn)
+}
+
+// Export calls fn with every row of the table in key order. Rows are read in
+// chunks of chunkSize by key inside a single transaction, so the export is
+// consistent and holds at most one chunk at a time.
+func (r Repository[T]) Export(ctx context.Context, chunkSize int, fn func(T) error) error {
+	tx, err := r.db.BeginTx(ctx, r.txOptions)
+	if err != nil {
+		return fmt.Errorf("error while starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.walk(ctx, tx, 0, chunkSize, fn); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Cursor is a scan over the whole table kept by the database in a transaction
+// of its own.
+type Cursor[T any] struct {
+	repository Repository[T]
+	tx         *sql.Tx
+}
+
+// DeclareCursor declares a cursor over the rows in key order. The cursor holds
+// a connection until it is closed.
+func (r Repository[T]) DeclareCursor(ctx context.Context) (*Cursor[T], error) {
+	// the transaction outlives the request opening it
+	tx, err := r.db.BeginTx(context.Background(), r.txOptions)
+	if err != nil {
+		return nil, fmt.Errorf("error while starting transaction: %v", err)
+	}
+	query := fmt.Sprintf("DECLARE scan NO SCROLL CURSOR FOR SELECT %s FROM %s ORDER BY %s;", r.columnList(), r.table, r.key)
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("error occured while running query: %s, error: %v", query, err)
+	}
+	return &Cursor[T]{repository: r, tx: tx}, nil
+}
+
+// Fetch returns the next n rows of the cursor.
+func (c *Cursor[T]) Fetch(ctx context.Context, n int) ([]T, error) {
+	return c.repository.query(ctx, c.tx, fmt.Sprintf("FETCH FORWARD %d FROM scan;", n))
+}
+
+// Close closes the cursor and ends its transaction.
+func (c *Cursor[T]) Close() error {
+	return c.tx.Rollback()
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// walk reads the rows with a key greater than after in batches of batchSize,
+// continuing each batch from the last key of the previous one. A batch is read
+// completely before fn sees its first row: rows closed halfway, by an error of
+// fn or by ctx, make some drivers such as ramsql panic or hang.
+func (r Repository[T]) walk(ctx context.Context, q querier, after, batchSize int, fn func(T) error) error {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s > $1 ORDER BY %s LIMIT $2;", r.columnList(), r.table, r.key, r.key)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch, err := r.query(ctx, q, query, after, batchSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		for _, item := range batch {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			after = r.keyOf(item)
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+	}
+}
+
+func (r Repository[T]) keyOf(item T) int {
+	return int(reflect.ValueOf(item).Field(r.fields[r.key]).Int())
+}
+
+func (r Repository[T]) columnList() string {
+	return strings.Join(r.columns, ", ")
+}
+
+func (r Repository[T]) query(ctx context.Context, q querier, query string, args ...any) ([]T, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error occured while running query: %s, error: %v", query, err)
+	}
+	defer rows.Close()
+
+	return r.scan(rows)
+}
+
+func (r Repository[T]) keys(ctx context.Context, q querier, query string, args ...any) ([]any, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error occured while running query: %s, error: %v", query, err)
+	}
+	defer rows.Close()
+
+	var keys []any
+	for rows.Next() {
+		var key int
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("error while parsing rows: %v", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating rows: %v", err)
+	}
+	return keys, nil
+}
+
+func (r Repository[T]) scan(rows *sql.Rows) ([]T, error) {
+	var items []T
+	err := r.each(rows, func(item T) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// each maps the rows one by one and stops at the first error returned by fn.
+// The rows left after an error are drained, see walk.
+func (r Repository[T]) each(rows *sql.Rows, fn func(T) error) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("error while reading columns: %v", err)
+	}
+
+	for rows.Next() {
+		var item T
+		value := reflect.ValueOf(&item).Elem()
+		destinations := make([]any, len(columns))
+		for i, column := range columns {
+			index, ok := r.fields[column]
+			if !ok {
+				drain(rows)
+				return fmt.Errorf("column %s of table %s is not mapped by any field", column, r.table)
+			}
+			destinations[i] = value.Field(index).Addr().Interface()
+		}
+		if err = rows.Scan(destinations...); err != nil {
+			drain(rows)
+			return fmt.Errorf("error while parsing rows: %v", err)
+		}
+		normalizeTimes(value)
+		if err = fn(item); err != nil {
+			drain(rows)
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error while iterating rows: %v", err)
+	}
+	return nil
+}
+
+func drain(rows *sql.Rows) {
+	for rows.Next() {
+	}
+}
+
+func mapFields(t reflect.Type) ([]string, map[string]int) {
+	var columns []string
+	fields := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		column := t.Field(i).Tag.Get("db")
+		if column == "" || column == "-" {
+			continue
+		}
+		columns = append(columns, column)
+		fields[column] = i
+	}
+	return columns, fields
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func normalizeTimes(value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		if field := value.Field(i); field.Type() == timeType {
+			field.Set(reflect.ValueOf(field.Interface().(time.Time).UTC()))
+		}
+	}
+}
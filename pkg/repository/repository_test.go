@@ -0,0 +1,489 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/krukkrz/pagination/pkg/repository"
+	_ "github.com/proullon/ramsql/driver"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type gadget struct {
+	Id        int       `db:"gadget_id"`
+	Name      string    `db:"name"`
+	Vendor    string    `db:"vendor"`
+	CreatedAt time.Time `db:"created_at"`
+	Ignored   string
+}
+
+func TestRepository(t *testing.T) {
+	db, err := sql.Open("ramsql", "Test generic repository")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	// columns are declared in a different order than the fields of gadget
+	initTable := `CREATE TABLE gadgets (created_at TIMESTAMP NOT NULL, vendor VARCHAR ( 100 ) NOT NULL, gadget_id BIGSERIAL PRIMARY KEY, name VARCHAR ( 100 ) NOT NULL);`
+	if _, err := db.Exec(initTable); err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+	createdAt := time.Date(2023, 3, 23, 20, 0, 0, 0, time.FixedZone("CET", 3600))
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+		if _, err := db.Exec("INSERT INTO gadgets (name, vendor, created_at) VALUES ($1, $2, $3);", name, "vendor "+name, createdAt); err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	repo := repository.New[gadget](db, "gadgets", "gadget_id")
+
+	testCases := []struct {
+		name        string
+		fetch       func() ([]gadget, error)
+		expectedIds []int
+	}{
+		{
+			name:        "offset strategy skips rows in key order",
+			fetch:       func() ([]gadget, error) { return repo.FetchOffset(context.Background(), 3, 9) },
+			expectedIds: []int{10, 11, 12},
+		},
+		{
+			name:        "cursor strategy starts at the given key",
+			fetch:       func() ([]gadget, error) { return repo.FetchCursor(context.Background(), 8, 4) },
+			expectedIds: []int{8, 9, 10, 11},
+		},
+		{
+			name:        "seek skips rows after the given key",
+			fetch:       func() ([]gadget, error) { return repo.FetchSeek(context.Background(), 5, 2, 3) },
+			expectedIds: []int{7, 8, 9},
+		},
+		{
+			name:        "rows before a key are read closest first",
+			fetch:       func() ([]gadget, error) { return repo.FetchBefore(context.Background(), 5, 3) },
+			expectedIds: []int{4, 3, 2},
+		},
+		{
+			name:  "cursor strategy returns nothing past the last key",
+			fetch: func() ([]gadget, error) { return repo.FetchCursor(context.Background(), 13, 4) },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := tc.fetch()
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+
+			var ids []int
+			for _, g := range actual {
+				ids = append(ids, g.Id)
+			}
+			if !reflect.DeepEqual(ids, tc.expectedIds) {
+				t.Errorf("unexpected ids returned, got: %v, expected: %v", ids, tc.expectedIds)
+			}
+		})
+	}
+
+	t.Run("counts rows before a key", func(t *testing.T) {
+		count, err := repo.CountBefore(context.Background(), 5)
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		if count != 4 {
+			t.Errorf("unexpected count, got: %d, expected: %d", count, 4)
+		}
+	})
+
+	t.Run("maps columns to fields by tags", func(t *testing.T) {
+		actual, err := repo.FetchOffset(context.Background(), 1, 0)
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+
+		expected := gadget{Id: 1, Name: "a", Vendor: "vendor a", CreatedAt: createdAt.UTC()}
+		if !reflect.DeepEqual(actual[0], expected) {
+			t.Errorf("unexpected row mapping, got: %+v, expected: %+v", actual[0], expected)
+		}
+	})
+}
+
+func TestExport(t *testing.T) {
+	db, err := sql.Open("ramsql", "Test repository export")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	initTable := `CREATE TABLE gadgets (gadget_id BIGSERIAL PRIMARY KEY, name VARCHAR ( 100 ) NOT NULL, vendor VARCHAR ( 100 ) NOT NULL, created_at TIMESTAMP NOT NULL);`
+	if _, err := db.Exec(initTable); err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+	for i := 0; i < 12; i++ {
+		if _, err := db.Exec("INSERT INTO gadgets (name, vendor, created_at) VALUES ($1, $2, $3);", "name", "vendor", time.Date(2023, 3, 23, 19, 0, 0, 0, time.UTC)); err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	repo := repository.New[gadget](db, "gadgets", "gadget_id")
+	// ramsql supports neither read-only transactions nor isolation levels
+	repo.SetTxOptions(nil)
+
+	for _, chunkSize := range []int{5, 6, 50} {
+		var ids []int
+		err := repo.Export(context.Background(), chunkSize, func(g gadget) error {
+			ids = append(ids, g.Id)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		if expected := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}; !reflect.DeepEqual(ids, expected) {
+			t.Errorf("unexpected ids exported in chunks of %d, got: %v, expected: %v", chunkSize, ids, expected)
+		}
+	}
+
+	t.Run("stops at the first error", func(t *testing.T) {
+		stop := errors.New("stop")
+		count := 0
+		err := repo.Export(context.Background(), 5, func(g gadget) error {
+			count++
+			if count == 3 {
+				return stop
+			}
+			return nil
+		})
+		if !errors.Is(err, stop) || count != 3 {
+			t.Errorf("expecting export to stop after 3 rows with the callback error, got: %d rows and %v", count, err)
+		}
+	})
+}
+
+func TestIterate(t *testing.T) {
+	db, err := sql.Open("ramsql", "Test repository iterate")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	initTable := `CREATE TABLE gadgets (gadget_id BIGSERIAL PRIMARY KEY, name VARCHAR ( 100 ) NOT NULL, vendor VARCHAR ( 100 ) NOT NULL, created_at TIMESTAMP NOT NULL);`
+	if _, err := db.Exec(initTable); err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+	for i := 0; i < 12; i++ {
+		if _, err := db.Exec("INSERT INTO gadgets (name, vendor, created_at) VALUES ($1, $2, $3);", "name", "vendor", time.Date(2023, 3, 23, 19, 0, 0, 0, time.UTC)); err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	repo := repository.New[gadget](db, "gadgets", "gadget_id")
+
+	testCases := []struct {
+		name        string
+		opts        repository.IterateOptions
+		expectedIds []int
+	}{
+		{
+			name:        "walks every row with the default batch size",
+			expectedIds: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+		},
+		{
+			name:        "walks every row in small batches",
+			opts:        repository.IterateOptions{BatchSize: 4},
+			expectedIds: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+		},
+		{
+			name:        "resumes after the given key",
+			opts:        repository.IterateOptions{BatchSize: 5, After: 9},
+			expectedIds: []int{10, 11, 12},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ids []int
+			err := repo.Iterate(context.Background(), tc.opts, func(g gadget) error {
+				ids = append(ids, g.Id)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if !reflect.DeepEqual(ids, tc.expectedIds) {
+				t.Errorf("unexpected ids returned, got: %v, expected: %v", ids, tc.expectedIds)
+			}
+		})
+	}
+
+	t.Run("stops once the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var ids []int
+		err := repo.Iterate(ctx, repository.IterateOptions{BatchSize: 3}, func(g gadget) error {
+			ids = append(ids, g.Id)
+			if g.Id == 2 {
+				cancel()
+			}
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expecting context error, got: %v", err)
+		}
+		if !reflect.DeepEqual(ids, []int{1, 2}) {
+			t.Errorf("expecting iteration to stop right after cancelling, got: %v", ids)
+		}
+	})
+}
+
+func TestNewPanicsOnUnmappedKey(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expecting New to panic when key column is not mapped")
+		}
+	}()
+	repository.New[gadget](nil, "gadgets", "id")
+}
+
+func TestFetchRange(t *testing.T) {
+	db, err := sql.Open("ramsql", "Test repository range")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	initTable := `CREATE TABLE gadgets (gadget_id BIGSERIAL PRIMARY KEY, name VARCHAR ( 100 ) NOT NULL, vendor VARCHAR ( 100 ) NOT NULL, created_at TIMESTAMP NOT NULL);`
+	if _, err := db.Exec(initTable); err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+	// ids 1 to 4 and 9 to 10 are created at the same time, ids 5 to 8 an hour
+	// later, so ties are broken by the key
+	start := time.Date(2023, 3, 23, 19, 0, 0, 0, time.UTC)
+	for i := 1; i <= 10; i++ {
+		createdAt := start
+		if i >= 5 && i <= 8 {
+			createdAt = start.Add(time.Hour)
+		}
+		if _, err := db.Exec("INSERT INTO gadgets (name, vendor, created_at) VALUES ($1, $2, $3);", "name", "vendor", createdAt); err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+
+	repo := repository.New[gadget](db, "gadgets", "gadget_id")
+
+	testCases := []struct {
+		name        string
+		rng         repository.Range
+		after       *repository.Position
+		limit       int
+		expectedIds []int
+	}{
+		{
+			name:        "orders ties by key",
+			limit:       7,
+			expectedIds: []int{1, 2, 3, 4, 9, 10, 5},
+		},
+		{
+			name:        "continues within a tie",
+			after:       &repository.Position{Time: start, Key: 3},
+			limit:       4,
+			expectedIds: []int{4, 9, 10, 5},
+		},
+		{
+			name:        "includes since and excludes until by default",
+			rng:         repository.Range{Since: start.Add(time.Hour), Until: start.Add(2 * time.Hour)},
+			limit:       10,
+			expectedIds: []int{5, 6, 7, 8},
+		},
+		{
+			name:  "excludes since when asked",
+			rng:   repository.Range{Since: start.Add(time.Hour), SinceExclusive: true},
+			limit: 10,
+		},
+		{
+			name:        "includes until when asked",
+			rng:         repository.Range{Until: start, UntilInclusive: true},
+			after:       &repository.Position{Time: start, Key: 4},
+			limit:       10,
+			expectedIds: []int{9, 10},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := repo.FetchRange(context.Background(), "created_at", tc.rng, tc.after, tc.limit)
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+
+			var ids []int
+			for _, g := range actual {
+				ids = append(ids, g.Id)
+				if !tc.rng.Contains(g.CreatedAt) {
+					t.Errorf("expecting range to contain returned row %d created at %s", g.Id, g.CreatedAt)
+				}
+			}
+			if !reflect.DeepEqual(ids, tc.expectedIds) {
+				t.Errorf("unexpected ids returned, got: %v, expected: %v", ids, tc.expectedIds)
+			}
+		})
+	}
+}
+
+func TestFetchChanges(t *testing.T) {
+	db, err := sql.Open("ramsql", "Test repository changes")
+	if err != nil {
+		t.Fatalf("sql.Open : Error : %s\n", err)
+	}
+	defer db.Close()
+
+	initTables := []string{
+		`CREATE TABLE gadgets (gadget_id BIGSERIAL PRIMARY KEY, name VARCHAR ( 100 ) NOT NULL, vendor VARCHAR ( 100 ) NOT NULL, created_at TIMESTAMP NOT NULL);`,
+		`CREATE TABLE changes (change_id BIGSERIAL PRIMARY KEY, resource VARCHAR ( 20 ) NOT NULL, item_id INT NOT NULL, operation VARCHAR ( 10 ) NOT NULL, txid BIGINT NOT NULL, changed_at TIMESTAMP NOT NULL);`,
+		`CREATE TABLE horizons (xmin BIGINT NOT NULL);`,
+	}
+	for _, q := range initTables {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+	createdAt := time.Date(2023, 3, 23, 19, 0, 0, 0, time.UTC)
+	for i := 1; i <= 3; i++ {
+		if _, err := db.Exec("INSERT INTO gadgets (name, vendor, created_at) VALUES ($1, $2, $3);", "name", "vendor", createdAt); err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+	// transaction 20 inserted gadgets 1 and 2 but committed after transaction
+	// 10 inserted gadget 3, gadget 4 was inserted and deleted by transaction 30
+	// which is still running, and the change of another resource is ignored
+	changes := []struct {
+		resource  string
+		item      int
+		operation string
+		txid      int
+	}{
+		{"gadgets", 1, repository.Insert, 20},
+		{"gadgets", 3, repository.Insert, 10},
+		{"gadgets", 2, repository.Insert, 20},
+		{"widgets", 1, repository.Insert, 15},
+		{"gadgets", 4, repository.Insert, 30},
+		{"gadgets", 4, repository.Delete, 30},
+	}
+	for _, c := range changes {
+		if _, err := db.Exec("INSERT INTO changes (resource, item_id, operation, txid, changed_at) VALUES ($1, $2, $3, $4, $5);", c.resource, c.item, c.operation, c.txid, createdAt); err != nil {
+			t.Fatalf("sql.Exec: Error: %s\n", err)
+		}
+	}
+	if _, err := db.Exec("INSERT INTO horizons (xmin) VALUES ($1);", 30); err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+
+	repo := repository.New[gadget](db, "gadgets", "gadget_id")
+	// ramsql supports neither read-only transactions nor isolation levels
+	repo.SetTxOptions(nil)
+	repo.SetHorizon("SELECT xmin FROM horizons;")
+
+	type change struct {
+		txid, id  int64
+		item      int
+		operation string
+		deleted   bool
+	}
+	testCases := []struct {
+		name     string
+		after    repository.ChangePosition
+		limit    int
+		horizon  int
+		expected []change
+	}{
+		{
+			name:    "orders changes by transaction below the horizon",
+			limit:   10,
+			horizon: 30,
+			expected: []change{
+				{txid: 10, id: 2, item: 3, operation: repository.Insert},
+				{txid: 20, id: 1, item: 1, operation: repository.Insert},
+				{txid: 20, id: 3, item: 2, operation: repository.Insert},
+			},
+		},
+		{
+			name:    "continues within a transaction",
+			after:   repository.ChangePosition{Txid: 20, Id: 1},
+			limit:   10,
+			horizon: 30,
+			expected: []change{
+				{txid: 20, id: 3, item: 2, operation: repository.Insert},
+			},
+		},
+		{
+			name:    "returns deleted items as tombstones once their transaction ended",
+			after:   repository.ChangePosition{Txid: 20, Id: 3},
+			limit:   10,
+			horizon: 31,
+			expected: []change{
+				{txid: 30, id: 5, item: 4, operation: repository.Insert, deleted: true},
+				{txid: 30, id: 6, item: 4, operation: repository.Delete, deleted: true},
+			},
+		},
+		{
+			name:    "limits across transactions",
+			after:   repository.ChangePosition{Txid: 10, Id: 2},
+			limit:   1,
+			horizon: 31,
+			expected: []change{
+				{txid: 20, id: 1, item: 1, operation: repository.Insert},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := db.Exec("UPDATE horizons SET xmin = $1 WHERE xmin > 0;", tc.horizon); err != nil {
+				t.Fatalf("sql.Exec: Error: %s\n", err)
+			}
+
+			actual, err := repo.FetchChanges(context.Background(), "gadgets", tc.after, tc.limit)
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+
+			var got []change
+			for _, c := range actual {
+				got = append(got, change{txid: c.Position.Txid, id: c.Position.Id, item: c.Key, operation: c.Operation, deleted: c.Item == nil})
+				if c.Item != nil && c.Item.Id != c.Key {
+					t.Errorf("expecting change of %d to carry its item, got: %d", c.Key, c.Item.Id)
+				}
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("unexpected changes returned, got: %v, expected: %v", got, tc.expected)
+			}
+		})
+	}
+
+	latestCases := []struct {
+		name     string
+		horizon  int
+		expected repository.ChangePosition
+	}{
+		{name: "latest change below the horizon", horizon: 30, expected: repository.ChangePosition{Txid: 20, Id: 3}},
+		{name: "latest change once every transaction ended", horizon: 31, expected: repository.ChangePosition{Txid: 30, Id: 6}},
+		{name: "no latest change below the oldest transaction", horizon: 10, expected: repository.ChangePosition{}},
+	}
+	for _, tc := range latestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := db.Exec("UPDATE horizons SET xmin = $1 WHERE xmin > 0;", tc.horizon); err != nil {
+				t.Fatalf("sql.Exec: Error: %s\n", err)
+			}
+
+			actual, err := repo.FetchLatestChange(context.Background(), "gadgets")
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("unexpected latest change returned, got: %v, expected: %v", actual, tc.expected)
+			}
+		})
+	}
+}
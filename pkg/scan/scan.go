@@ -0,0 +1,219 @@
+package scan
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound     = errors.New("scan not found")
+	ErrTooManyScans = errors.New("too many open scans")
+)
+
+// Cursor is a position in a scan kept by the database until it is closed.
+type Cursor[T any] interface {
+	// Fetch returns the next n rows, fewer once the scan reached its end.
+	Fetch(ctx context.Context, n int) ([]T, error)
+	Close() error
+}
+
+// Opener opens a cursor over a whole table.
+type Opener[T any] func(ctx context.Context) (Cursor[T], error)
+
+type Config struct {
+	// TTL is how long a scan stays open without being fetched.
+	TTL time.Duration
+	// MaxPerClient limits the scans a single client keeps open.
+	MaxPerClient int
+	// MaxOpen limits the scans open at once, each of them holds a connection.
+	MaxOpen int
+}
+
+var DefaultConfig = Config{
+	TTL:          time.Minute,
+	MaxPerClient: 2,
+	MaxOpen:      10,
+}
+
+// Info describes an open scan.
+type Info struct {
+	Id        string
+	ExpiresAt time.Time
+}
+
+// Manager keeps the open scans of every client and closes the ones left idle
+// for longer than the TTL.
+type Manager[T any] struct {
+	open   Opener[T]
+	config Config
+
+	mu       sync.Mutex
+	scans    map[string]*scan[T]
+	byClient map[string]int
+}
+
+type scan[T any] struct {
+	id     string
+	client string
+	// mu serializes fetches, a cursor belongs to a single connection
+	mu        sync.Mutex
+	cursor    Cursor[T]
+	timer     *time.Timer
+	expiresAt time.Time
+	closed    bool
+}
+
+func NewManager[T any](open Opener[T], config Config) *Manager[T] {
+	return &Manager[T]{
+		open:     open,
+		config:   config,
+		scans:    make(map[string]*scan[T]),
+		byClient: make(map[string]int),
+	}
+}
+
+// Open opens a new scan for the client.
+func (m *Manager[T]) Open(ctx context.Context, client string) (Info, error) {
+	id, err := newId()
+	if err != nil {
+		return Info{}, err
+	}
+
+	// the slot is taken before opening, so concurrent opens cannot exceed the limits
+	m.mu.Lock()
+	if m.byClient[client] >= m.config.MaxPerClient || len(m.scans) >= m.config.MaxOpen {
+		m.mu.Unlock()
+		return Info{}, ErrTooManyScans
+	}
+	s := &scan[T]{id: id, client: client}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m.scans[id] = s
+	m.byClient[client]++
+	m.mu.Unlock()
+
+	cursor, err := m.open(ctx)
+	if err != nil {
+		s.closed = true
+		m.forget(s)
+		return Info{}, fmt.Errorf("error while opening scan: %v", err)
+	}
+	s.cursor = cursor
+	s.expiresAt = time.Now().Add(m.config.TTL)
+	s.timer = time.AfterFunc(m.config.TTL, func() {
+		m.expire(s)
+	})
+	log.Printf("opened scan %s for %s", id, client)
+	return Info{Id: id, ExpiresAt: s.expiresAt}, nil
+}
+
+// Fetch returns the next n rows of the scan and keeps it open for another TTL.
+// The scan is closed once it returned its last row, which is told by done.
+func (m *Manager[T]) Fetch(ctx context.Context, id, client string, n int) (rows []T, info Info, done bool, err error) {
+	s, err := m.lookup(id, client)
+	if err != nil {
+		return nil, Info{}, false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, Info{}, false, ErrNotFound
+	}
+	s.timer.Stop()
+	rows, err = s.cursor.Fetch(ctx, n)
+	if err != nil || len(rows) < n {
+		m.close(s)
+		return rows, Info{Id: id}, true, err
+	}
+	s.expiresAt = time.Now().Add(m.config.TTL)
+	s.timer.Reset(m.config.TTL)
+	return rows, Info{Id: id, ExpiresAt: s.expiresAt}, false, nil
+}
+
+// Close closes the scan of the client.
+func (m *Manager[T]) Close(id, client string) error {
+	s, err := m.lookup(id, client)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrNotFound
+	}
+	return m.close(s)
+}
+
+// Shutdown closes every open scan.
+func (m *Manager[T]) Shutdown() {
+	m.mu.Lock()
+	scans := make([]*scan[T], 0, len(m.scans))
+	for _, s := range m.scans {
+		scans = append(scans, s)
+	}
+	m.mu.Unlock()
+
+	for _, s := range scans {
+		m.Close(s.id, s.client)
+	}
+}
+
+// expire closes the scan once it was left idle for the TTL. The timer may fire
+// while a fetch holds the scan, which moves the expiry, so it is checked again.
+func (m *Manager[T]) expire(s *scan[T]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || time.Now().Before(s.expiresAt) {
+		return
+	}
+	log.Printf("closing idle scan %s", s.id)
+	m.close(s)
+}
+
+func (m *Manager[T]) lookup(id, client string) (*scan[T], error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// scans of other clients are not revealed
+	s, ok := m.scans[id]
+	if !ok || s.client != client {
+		return nil, ErrNotFound
+	}
+	return s, nil
+}
+
+// close closes the cursor of the scan, it must be called with the mutex of the
+// scan held.
+func (m *Manager[T]) close(s *scan[T]) error {
+	s.closed = true
+	s.timer.Stop()
+	m.forget(s)
+	if err := s.cursor.Close(); err != nil {
+		return fmt.Errorf("error while closing scan %s: %v", s.id, err)
+	}
+	return nil
+}
+
+func (m *Manager[T]) forget(s *scan[T]) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.scans, s.id)
+	m.byClient[s.client]--
+	if m.byClient[s.client] == 0 {
+		delete(m.byClient, s.client)
+	}
+}
+
+func newId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error while generating scan id: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,118 @@
+package scan_test
+
+import (
+	"context"
+	"errors"
+	"github.com/krukkrz/pagination/pkg/scan"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type cursor struct {
+	next   int
+	last   int
+	closed *atomic.Int32
+}
+
+func (c *cursor) Fetch(ctx context.Context, n int) ([]int, error) {
+	var rows []int
+	for ; n > 0 && c.next <= c.last; n-- {
+		rows = append(rows, c.next)
+		c.next++
+	}
+	return rows, nil
+}
+
+func (c *cursor) Close() error {
+	c.closed.Add(1)
+	return nil
+}
+
+func TestManager(t *testing.T) {
+	var closed atomic.Int32
+	open := func(ctx context.Context) (scan.Cursor[int], error) {
+		return &cursor{next: 1, last: 5, closed: &closed}, nil
+	}
+
+	t.Run("fetches continue where the previous one stopped", func(t *testing.T) {
+		manager := scan.NewManager(open, scan.DefaultConfig)
+		info, err := manager.Open(context.Background(), "client")
+		if err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+
+		var ids []int
+		for _, expectedDone := range []bool{false, false, true} {
+			rows, _, done, err := manager.Fetch(context.Background(), info.Id, "client", 2)
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if done != expectedDone {
+				t.Errorf("unexpected done after %v, got: %v", ids, done)
+			}
+			ids = append(ids, rows...)
+		}
+		if expected := []int{1, 2, 3, 4, 5}; !reflect.DeepEqual(ids, expected) {
+			t.Errorf("unexpected rows, got: %v, expected: %v", ids, expected)
+		}
+		if _, _, _, err := manager.Fetch(context.Background(), info.Id, "client", 2); !errors.Is(err, scan.ErrNotFound) {
+			t.Errorf("expecting finished scan to be closed, got: %v", err)
+		}
+	})
+
+	t.Run("scans of other clients are not found", func(t *testing.T) {
+		manager := scan.NewManager(open, scan.DefaultConfig)
+		info, _ := manager.Open(context.Background(), "client")
+		if _, _, _, err := manager.Fetch(context.Background(), info.Id, "other", 2); !errors.Is(err, scan.ErrNotFound) {
+			t.Errorf("expecting not found, got: %v", err)
+		}
+		if err := manager.Close(info.Id, "other"); !errors.Is(err, scan.ErrNotFound) {
+			t.Errorf("expecting not found, got: %v", err)
+		}
+	})
+
+	t.Run("open scans are limited per client and in total", func(t *testing.T) {
+		manager := scan.NewManager(open, scan.Config{TTL: time.Minute, MaxPerClient: 2, MaxOpen: 3})
+		first, _ := manager.Open(context.Background(), "a")
+		manager.Open(context.Background(), "a")
+		if _, err := manager.Open(context.Background(), "a"); !errors.Is(err, scan.ErrTooManyScans) {
+			t.Errorf("expecting client limit, got: %v", err)
+		}
+		manager.Open(context.Background(), "b")
+		if _, err := manager.Open(context.Background(), "c"); !errors.Is(err, scan.ErrTooManyScans) {
+			t.Errorf("expecting total limit, got: %v", err)
+		}
+
+		if err := manager.Close(first.Id, "a"); err != nil {
+			t.Fatalf("unexpected error occured: %v", err)
+		}
+		if _, err := manager.Open(context.Background(), "a"); err != nil {
+			t.Errorf("expecting a closed scan to free its slot, got: %v", err)
+		}
+		manager.Shutdown()
+	})
+
+	t.Run("idle scans are closed after the ttl", func(t *testing.T) {
+		manager := scan.NewManager(open, scan.Config{TTL: 20 * time.Millisecond, MaxPerClient: 1, MaxOpen: 1})
+		before := closed.Load()
+		info, _ := manager.Open(context.Background(), "client")
+
+		time.Sleep(10 * time.Millisecond)
+		if _, _, _, err := manager.Fetch(context.Background(), info.Id, "client", 1); err != nil {
+			t.Fatalf("expecting fetch to keep the scan open, got: %v", err)
+		}
+		time.Sleep(60 * time.Millisecond)
+
+		if _, _, _, err := manager.Fetch(context.Background(), info.Id, "client", 1); !errors.Is(err, scan.ErrNotFound) {
+			t.Errorf("expecting idle scan to be closed, got: %v", err)
+		}
+		if closed.Load() != before+1 {
+			t.Errorf("expecting the cursor to be closed")
+		}
+		if _, err := manager.Open(context.Background(), "client"); err != nil {
+			t.Errorf("expecting an expired scan to free its slot, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"github.com/krukkrz/pagination/pkg/repository"
+	"log"
+	"time"
+)
+
+// changesInterval is how often the change feeds are polled for writes made
+// outside of the process.
+const changesInterval = time.Second
+
+type changeFeed[T any] func(ctx context.Context, after repository.ChangePosition, limit int) ([]repository.Change[T], error)
+
+// latestChange returns the position of the last change of a feed.
+type latestChange func(ctx context.Context) (repository.ChangePosition, error)
+
+// watchChanges subscribes to a change feed. Once subscribed, it polls the feed
+// until ctx is done and calls the listener whenever new changes show up.
+func watchChanges[T any](ctx context.Context, feed changeFeed[T], latest latestChange) func(listener func()) {
+	return func(listener func()) {
+		go func() {
+			// changes recorded before the subscription are of no interest
+			position, err := latest(ctx)
+			started := err == nil
+			if err != nil {
+				log.Printf("error while reading the latest change: %v", err)
+			}
+			ticker := time.NewTicker(changesInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				if !started {
+					if position, err = latest(ctx); err != nil {
+						log.Printf("error while reading the latest change: %v", err)
+						continue
+					}
+					started = true
+					continue
+				}
+				next, changed, err := readChanges(ctx, feed, position)
+				if err != nil {
+					log.Printf("error while reading changes: %v", err)
+					continue
+				}
+				position = next
+				if changed {
+					listener()
+				}
+			}
+		}()
+	}
+}
+
+// readChanges reads the feed after the position to its end, it returns the
+// position of the last change and whether there was any.
+func readChanges[T any](ctx context.Context, feed changeFeed[T], position repository.ChangePosition) (repository.ChangePosition, bool, error) {
+	const limit = 1000
+	changed := false
+	for {
+		changes, err := feed(ctx, position, limit)
+		if err != nil {
+			return position, changed, err
+		}
+		if len(changes) > 0 {
+			changed = true
+			position = changes[len(changes)-1].Position
+		}
+		if len(changes) < limit {
+			return position, changed, nil
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"github.com/krukkrz/pagination/pkg/repository"
+	"time"
+)
+
+// change is a write recorded by the table. Every write is a transaction of its
+// own, committed in the order of the changes.
+type change struct {
+	id        int64
+	operation string
+	key       int
+	at        time.Time
+}
+
+// record records a write, it must be called with mu held.
+func (t *Table[T]) record(operation string, key int) {
+	t.changes = append(t.changes, change{
+		id:        int64(len(t.changes) + 1),
+		operation: operation,
+		key:       key,
+		at:        time.Now().UTC(),
+	})
+}
+
+// Changes returns limit changes after the position with the current state of
+// the changed rows, the way repository.Repository reads the changes table.
+func (t *Table[T]) Changes(ctx context.Context, after repository.ChangePosition, limit int) ([]repository.Change[T], error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var changes []repository.Change[T]
+	for _, c := range t.changes {
+		if len(changes) == limit {
+			break
+		}
+		if c.id < after.Txid || c.id == after.Txid && c.id <= after.Id {
+			continue
+		}
+		recorded := repository.Change[T]{
+			Position:  repository.ChangePosition{Txid: c.id, Id: c.id},
+			Operation: c.operation,
+			Key:       c.key,
+			UpdatedAt: c.at,
+		}
+		if i := t.search(c.key); i < len(t.rows) && t.key(t.rows[i]) == c.key {
+			row := t.rows[i]
+			recorded.Item = &row
+		}
+		changes = append(changes, recorded)
+	}
+	return changes, nil
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/repository"
+	"github.com/krukkrz/pagination/pkg/scan"
+	"sort"
+	"time"
+)
+
+type BookRepository struct {
+	*Table[booksModels.Book]
+}
+
+func NewBookRepository() *BookRepository {
+	return &BookRepository{
+		Table: NewTable(func(b booksModels.Book) int { return b.Id }),
+	}
+}
+
+func (r BookRepository) FetchAll(ctx context.Context, limit, offset int) ([]booksModels.Book, error) {
+	return r.FetchOffset(ctx, limit, offset)
+}
+
+func (r BookRepository) FetchAllAsOf(ctx context.Context, asOf time.Time, limit, offset int) ([]booksModels.Book, error) {
+	return r.FetchOffsetWhere(ctx, func(b booksModels.Book) bool {
+		return !b.CreatedAt.After(asOf)
+	}, limit, offset)
+}
+
+type CarRepository struct {
+	*Table[carsModels.Car]
+}
+
+func NewCarRepository() *CarRepository {
+	return &CarRepository{
+		Table: NewTable(func(c carsModels.Car) int { return c.Id }),
+	}
+}
+
+func (r CarRepository) FetchAll(ctx context.Context, cursor, limit int) ([]carsModels.Car, error) {
+	return r.FetchCursor(ctx, cursor, limit)
+}
+
+func (r CarRepository) Scan(ctx context.Context) (scan.Cursor[carsModels.Car], error) {
+	return r.Table.Scan(ctx)
+}
+
+func (r CarRepository) FetchCreated(ctx context.Context, rng repository.Range, after *repository.Position, limit int) ([]carsModels.Car, error) {
+	var cars []carsModels.Car
+	err := r.Export(ctx, 0, func(c carsModels.Car) error {
+		if rng.Contains(c.CreatedAt) && (after == nil || after.Less(position(c))) {
+			cars = append(cars, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(cars, func(i, j int) bool {
+		return position(cars[i]).Less(position(cars[j]))
+	})
+	if len(cars) > limit {
+		cars = cars[:limit]
+	}
+	return cars, nil
+}
+
+func position(c carsModels.Car) repository.Position {
+	return repository.Position{Time: c.CreatedAt, Key: c.Id}
+}
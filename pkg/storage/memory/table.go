@@ -0,0 +1,273 @@
+package memory
+
+import (
+	"context"
+	"github.com/krukkrz/pagination/pkg/repository"
+	"sort"
+	"sync"
+)
+
+// Table keeps rows sorted by their integer key and pages through them the same
+// way repository.Repository pages through a SQL table. Writes replace the rows
+// instead of changing them in place, so readers can keep a snapshot.
+type Table[T any] struct {
+	mu        sync.RWMutex
+	rows      []T
+	key       func(T) int
+	listeners []func()
+	// changes records every write, see Changes
+	changes []change
+}
+
+func NewTable[T any](key func(T) int) *Table[T] {
+	return &Table[T]{key: key}
+}
+
+// OnChange registers a listener called after every write to the table.
+func (t *Table[T]) OnChange(listener func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.listeners = append(t.listeners, listener)
+}
+
+func (t *Table[T]) Insert(rows ...T) {
+	t.mu.Lock()
+	updated := append(append(make([]T, 0, len(t.rows)+len(rows)), t.rows...), rows...)
+	sort.SliceStable(updated, func(i, j int) bool {
+		return t.key(updated[i]) < t.key(updated[j])
+	})
+	t.rows = updated
+	for _, row := range rows {
+		t.record(repository.Insert, t.key(row))
+	}
+	listeners := t.listeners
+	t.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener()
+	}
+}
+
+// Update replaces the rows with the keys of the given rows, rows which do not
+// exist are ignored.
+func (t *Table[T]) Update(rows ...T) {
+	byKey := make(map[int]T, len(rows))
+	for _, row := range rows {
+		byKey[t.key(row)] = row
+	}
+
+	t.mu.Lock()
+	updated := append(make([]T, 0, len(t.rows)), t.rows...)
+	for i, row := range updated {
+		if replacement, ok := byKey[t.key(row)]; ok {
+			updated[i] = replacement
+			t.record(repository.Update, t.key(row))
+		}
+	}
+	t.rows = updated
+	listeners := t.listeners
+	t.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener()
+	}
+}
+
+// Delete removes the rows with the given keys.
+func (t *Table[T]) Delete(keys ...int) {
+	deleted := make(map[int]bool, len(keys))
+	for _, key := range keys {
+		deleted[key] = true
+	}
+
+	t.mu.Lock()
+	updated := make([]T, 0, len(t.rows))
+	for _, row := range t.rows {
+		if !deleted[t.key(row)] {
+			updated = append(updated, row)
+			continue
+		}
+		t.record(repository.Delete, t.key(row))
+	}
+	t.rows = updated
+	listeners := t.listeners
+	t.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener()
+	}
+}
+
+// Keys returns the keys of every row in order.
+func (t *Table[T]) Keys() []int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	keys := make([]int, len(t.rows))
+	for i, row := range t.rows {
+		keys[i] = t.key(row)
+	}
+	return keys
+}
+
+func (t *Table[T]) FetchOffset(ctx context.Context, limit, offset int) ([]T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if offset < 0 {
+		offset = 0
+	}
+	return t.page(offset, limit), nil
+}
+
+// FetchOffsetWhere pages through the rows for which keep returns true.
+func (t *Table[T]) FetchOffsetWhere(ctx context.Context, keep func(T) bool, limit, offset int) ([]T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var page []T
+	for _, row := range t.rows {
+		if len(page) == limit {
+			break
+		}
+		if !keep(row) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		page = append(page, row)
+	}
+	return page, nil
+}
+
+// FetchBefore returns limit rows with a key lower than cursor, the closest one
+// first.
+func (t *Table[T]) FetchBefore(ctx context.Context, cursor, limit int) ([]T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var page []T
+	for i := t.search(cursor) - 1; i >= 0 && len(page) < limit; i-- {
+		page = append(page, t.rows[i])
+	}
+	return page, nil
+}
+
+// CountBefore returns the number of rows with a key lower than cursor.
+func (t *Table[T]) CountBefore(ctx context.Context, cursor int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.search(cursor), nil
+}
+
+// Checkpoints returns the key of every nth row, starting with the first row.
+func (t *Table[T]) Checkpoints(ctx context.Context, every int) ([]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var keys []int
+	for i := 0; i < len(t.rows); i += every {
+		keys = append(keys, t.key(t.rows[i]))
+	}
+	return keys, nil
+}
+
+// FetchSeek returns limit rows starting skip rows after the row with key from.
+func (t *Table[T]) FetchSeek(ctx context.Context, from, skip, limit int) ([]T, error) {
+	return t.FetchOffsetWhere(ctx, func(row T) bool {
+		return t.key(row) >= from
+	}, limit, skip)
+}
+
+// Cursor is a scan over the rows of a table as they were when it was opened.
+type Cursor[T any] struct {
+	rows []T
+}
+
+// Scan opens a cursor over the rows in key order.
+func (t *Table[T]) Scan(ctx context.Context) (*Cursor[T], error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	// rows are copied on write, so the slice is never modified
+	return &Cursor[T]{rows: t.rows}, nil
+}
+
+// Fetch returns the next n rows of the cursor.
+func (c *Cursor[T]) Fetch(ctx context.Context, n int) ([]T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if n > len(c.rows) {
+		n = len(c.rows)
+	}
+	page := c.rows[:n:n]
+	c.rows = c.rows[n:]
+	return page, nil
+}
+
+func (c *Cursor[T]) Close() error {
+	return nil
+}
+
+func (t *Table[T]) FetchCursor(ctx context.Context, cursor, limit int) ([]T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.page(t.search(cursor), limit), nil
+}
+
+// search returns the index of the first row with a key not lower than cursor,
+// it must be called with mu held.
+func (t *Table[T]) search(cursor int) int {
+	return sort.Search(len(t.rows), func(i int) bool {
+		return t.key(t.rows[i]) >= cursor
+	})
+}
+
+// Export calls fn with every row of the snapshot taken when the export starts.
+// The context is checked once every chunkSize rows.
+func (t *Table[T]) Export(ctx context.Context, chunkSize int, fn func(T) error) error {
+	t.mu.RLock()
+	rows := t.rows
+	t.mu.RUnlock()
+
+	for i, row := range rows {
+		if chunkSize <= 0 || i%chunkSize == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Table[T]) page(start, limit int) []T {
+	if start >= len(t.rows) || limit <= 0 {
+		return nil
+	}
+	end := start + limit
+	if end > len(t.rows) {
+		end = len(t.rows)
+	}
+	return append([]T(nil), t.rows[start:end]...)
+}
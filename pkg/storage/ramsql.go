@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	_ "github.com/proullon/ramsql/driver"
+	"sync/atomic"
+)
+
+// ramsql only orders ids numerically when it generates them itself, so the
+// tables use BIGSERIAL and rows are inserted without explicit ids.
+var ramsqlSchema = []string{
+	`CREATE TABLE books (book_id BIGSERIAL PRIMARY KEY, title VARCHAR ( 100 ) NOT NULL, author VARCHAR ( 100 ) NOT NULL, created_at TIMESTAMP NOT NULL);`,
+	`CREATE TABLE cars (car_id BIGSERIAL PRIMARY KEY, brand VARCHAR ( 100 ) NOT NULL, model VARCHAR ( 100 ) NOT NULL, created_at TIMESTAMP NOT NULL);`,
+	`CREATE TABLE api_keys (key_id VARCHAR ( 100 ) PRIMARY KEY, key_hash VARCHAR ( 64 ) NOT NULL, scopes VARCHAR ( 500 ) NOT NULL);`,
+}
+
+var ramsqlInstances atomic.Int64
+
+func openRamsql(size int) (*sql.DB, error) {
+	db, err := sql.Open("ramsql", fmt.Sprintf("pagination-%d", ramsqlInstances.Add(1)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, statement := range ramsqlSchema {
+		if _, err := db.Exec(statement); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("error while creating ramsql schema: %v", err)
+		}
+	}
+
+	if err := Seed(context.Background(), db, size, size); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
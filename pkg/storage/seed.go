@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"time"
+)
+
+// Seed inserts generated books and cars into the database. Ids are left to the
+// database, so seeding can be repeated on tables which already have rows.
+func Seed(ctx context.Context, db *sql.DB, books, cars int) error {
+	for _, b := range generateBooks(books) {
+		if _, err := db.ExecContext(ctx, "INSERT INTO books (title, author, created_at) VALUES ($1, $2, $3);", b.Title, b.Author, b.CreatedAt); err != nil {
+			return fmt.Errorf("error while seeding books: %v", err)
+		}
+	}
+	for _, c := range generateCars(cars) {
+		if _, err := db.ExecContext(ctx, "INSERT INTO cars (brand, model, created_at) VALUES ($1, $2, $3);", c.Brand, c.Model, c.CreatedAt); err != nil {
+			return fmt.Errorf("error while seeding cars: %v", err)
+		}
+	}
+	return nil
+}
+
+// generateBooks and generateCars produce the same rows as db/sql/02_fill_tables.sql.
+func generateBooks(n int) []booksModels.Book {
+	createdAt := seedTimestamp()
+	var result []booksModels.Book
+	for i := 1; i <= n; i++ {
+		result = append(result, booksModels.Book{
+			Id:        i,
+			Title:     fmt.Sprintf("Title %d", i),
+			Author:    fmt.Sprintf("Author %d", i),
+			CreatedAt: createdAt,
+		})
+	}
+	return result
+}
+
+func generateCars(n int) []carsModels.Car {
+	createdAt := seedTimestamp()
+	var result []carsModels.Car
+	for i := 1; i <= n; i++ {
+		result = append(result, carsModels.Car{
+			Id:        i,
+			Brand:     fmt.Sprintf("Brand %d", i),
+			Model:     fmt.Sprintf("Model %d", i),
+			CreatedAt: createdAt,
+		})
+	}
+	return result
+}
+
+func seedTimestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
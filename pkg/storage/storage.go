@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/books"
+	booksModels "github.com/krukkrz/pagination/pkg/books/model"
+	"github.com/krukkrz/pagination/pkg/cache"
+	"github.com/krukkrz/pagination/pkg/cars"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/checkpoint"
+	"github.com/krukkrz/pagination/pkg/coalesce"
+	"github.com/krukkrz/pagination/pkg/consistency"
+	"github.com/krukkrz/pagination/pkg/database"
+	"github.com/krukkrz/pagination/pkg/storage/memory"
+	"log"
+)
+
+const (
+	Postgres = "postgres"
+	Ramsql   = "ramsql"
+	Memory   = "memory"
+)
+
+// seedSize matches the number of rows inserted by db/sql/02_fill_tables.sql.
+const seedSize = 200
+
+type Storage struct {
+	Books api.BookRepository
+	Cars  api.CarRepository
+	// BookExporter and CarExporter stream whole tables and are neither cached
+	// nor coalesced. They are nil for ramsql, which panics or hangs when a
+	// client going away closes the rows of an export halfway.
+	BookExporter api.BookExporter
+	CarExporter  api.CarExporter
+	// BookSnapshots pages through books as they were when a consistent
+	// pagination started, it is neither cached nor coalesced.
+	BookSnapshots api.SnapshotBookRepository
+	// CarScanner declares database cursors, it is nil when the database has none.
+	CarScanner api.CarScanner
+	// AroundCars and CountCars serve pages centred on a car and its position.
+	AroundCars api.AroundCarRepository
+	CountCars  api.CountCarRepository
+	// CreatedCars serves the cars created within a time range.
+	CreatedCars api.RangeCarRepository
+	// BookChanges and CarChanges serve the change feeds, they are nil when the
+	// database records no changes.
+	BookChanges api.BookChangeFeed
+	CarChanges  api.CarChangeFeed
+	// CarCheckpoints and SeekCars serve numbered pages of cars, they are nil
+	// when the database has no window functions.
+	CarCheckpoints checkpoint.Loader
+	SeekCars       api.SeekCarRepository
+	db             *sql.DB
+	// onChange subscribes to writes of a resource, keyed by resource. The
+	// memory storage sees every write, postgres polls its change feeds and
+	// ramsql sees none.
+	onChange map[string]func(listener func())
+	// stop ends the polling of the change feeds.
+	stop context.CancelFunc
+	// checked are the tables written by the consistency checker, keyed by resource.
+	checked map[string]consistency.Store
+}
+
+// Open opens the storage of the given kind. The database config is only used
+// by the postgres storage, the others are seeded in the process.
+func Open(kind string, dbConfig database.Config) (*Storage, error) {
+	log.Printf("opening %s storage", kind)
+	switch kind {
+	case Postgres:
+		db, err := database.Connect(dbConfig)
+		if err != nil {
+			return nil, err
+		}
+		return sqlStorage(db, true), nil
+	case Ramsql:
+		db, err := openRamsql(seedSize)
+		if err != nil {
+			return nil, err
+		}
+		// ramsql supports neither read-only transactions nor isolation levels
+		return sqlStorage(db, false), nil
+	case Memory:
+		return memoryStorage(seedSize), nil
+	default:
+		return nil, fmt.Errorf("unknown storage: %s, expected one of: %s, %s, %s", kind, Postgres, Ramsql, Memory)
+	}
+}
+
+// UseCoalescing lets concurrent requests for the same page share a single
+// query. It should be enabled before UseCache, so only cache misses coalesce.
+func (s *Storage) UseCoalescing() {
+	s.Books = coalesce.Books(s.Books)
+	s.Cars = coalesce.Cars(s.Cars)
+}
+
+// UseCache puts the page cache in front of both repositories. Pages of a
+// resource are invalidated whenever it is written, postgres picks writes up
+// from its change feeds within a second. ramsql records no changes, so its
+// writes only show once the cached pages expire.
+func (s *Storage) UseCache(c *cache.Cache) {
+	s.Books = cache.Books(s.Books, c)
+	s.Cars = cache.Cars(s.Cars, c)
+	for resource, subscribe := range s.onChange {
+		resource := resource
+		subscribe(func() {
+			c.Invalidate(resource)
+		})
+	}
+}
+
+// Checked returns the table of a resource for the consistency checker.
+func (s Storage) Checked(resource string) (consistency.Store, bool) {
+	store, ok := s.checked[resource]
+	return store, ok
+}
+
+// DB returns the database behind the storage, or nil for the memory storage.
+func (s Storage) DB() *sql.DB {
+	return s.db
+}
+
+func (s Storage) Close() error {
+	if s.stop != nil {
+		s.stop()
+	}
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func sqlStorage(db *sql.DB, postgres bool) *Storage {
+	var bookOptions []books.Option
+	if !postgres {
+		// ramsql can not join a subquery
+		bookOptions = append(bookOptions, books.WithDeferredJoin(0))
+	}
+	bookRepository := books.NewRepository(db, bookOptions...)
+	carRepository := cars.NewRepository(db)
+	if !postgres {
+		bookRepository.SetTxOptions(nil)
+		carRepository.SetTxOptions(nil)
+	}
+	s := &Storage{
+		Books:         bookRepository,
+		Cars:          carRepository,
+		BookSnapshots: bookRepository,
+		AroundCars:    carRepository,
+		CountCars:     carRepository,
+		CreatedCars:   carRepository,
+		db:            db,
+		checked: map[string]consistency.Store{
+			"books": consistency.SQLBooks(db),
+			"cars":  consistency.SQLCars(db),
+		},
+	}
+	// ramsql supports neither cursors, window functions nor triggers
+	if postgres {
+		s.BookExporter = bookRepository
+		s.CarExporter = carRepository
+		s.CarScanner = carRepository
+		s.BookChanges = bookRepository
+		s.CarChanges = carRepository
+		var ctx context.Context
+		ctx, s.stop = context.WithCancel(context.Background())
+		s.onChange = map[string]func(func()){
+			"books": watchChanges[booksModels.Book](ctx, bookRepository.Changes, bookRepository.LatestChange),
+			"cars":  watchChanges[carsModels.Car](ctx, carRepository.Changes, carRepository.LatestChange),
+		}
+		s.CarCheckpoints = carRepository.Checkpoints
+		s.SeekCars = carRepository
+	}
+	return s
+}
+
+func memoryStorage(size int) *Storage {
+	bookRepository := memory.NewBookRepository()
+	bookRepository.Insert(generateBooks(size)...)
+	carRepository := memory.NewCarRepository()
+	carRepository.Insert(generateCars(size)...)
+	return &Storage{
+		Books:          bookRepository,
+		Cars:           carRepository,
+		BookExporter:   bookRepository,
+		CarExporter:    carRepository,
+		BookSnapshots:  bookRepository,
+		CarScanner:     carRepository,
+		AroundCars:     carRepository,
+		CountCars:      carRepository,
+		CreatedCars:    carRepository,
+		BookChanges:    bookRepository,
+		CarChanges:     carRepository,
+		CarCheckpoints: carRepository.Checkpoints,
+		SeekCars:       carRepository,
+		onChange: map[string]func(func()){
+			"books": bookRepository.OnChange,
+			"cars":  carRepository.OnChange,
+		},
+		checked: map[string]consistency.Store{
+			"books": consistency.MemoryBooks(bookRepository),
+			"cars":  consistency.MemoryCars(carRepository),
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package storage_test
+
+import (
+	"context"
+	carsModels "github.com/krukkrz/pagination/pkg/cars/model"
+	"github.com/krukkrz/pagination/pkg/database"
+	"github.com/krukkrz/pagination/pkg/storage"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	for _, kind := range []string{storage.Memory, storage.Ramsql} {
+		t.Run(kind, func(t *testing.T) {
+			store, err := storage.Open(kind, database.DefaultConfig)
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			defer store.Close()
+
+			books, err := store.Books.FetchAll(context.Background(), 10, 195)
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if len(books) != 5 || books[0].Id != 196 || books[0].Title != "Title 196" {
+				t.Errorf("unexpected last page of books: %+v", books)
+			}
+
+			cars, err := store.Cars.FetchAll(context.Background(), 199, 10)
+			if err != nil {
+				t.Fatalf("unexpected error occured: %v", err)
+			}
+			if len(cars) != 2 || cars[0].Id != 199 || cars[1].Model != "Model 200" {
+				t.Errorf("unexpected last page of cars: %+v", cars)
+			}
+
+			if kind == storage.Ramsql {
+				if store.CarExporter != nil {
+					t.Errorf("expecting no exporter for ramsql")
+				}
+				return
+			}
+			count := 0
+			err = store.CarExporter.Export(context.Background(), 64, func(car carsModels.Car) error {
+				count++
+				if car.Id != count {
+					t.Fatalf("expecting car %d to be exported next, got: %d", count, car.Id)
+				}
+				return nil
+			})
+			if err != nil || count != 200 {
+				t.Errorf("expecting all 200 cars to be exported, got: %d and %v", count, err)
+			}
+		})
+	}
+
+	t.Run("unknown storage", func(t *testing.T) {
+		if _, err := storage.Open("mongo", database.DefaultConfig); err == nil {
+			t.Errorf("expecting error for unknown storage")
+		}
+	})
+}
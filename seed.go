@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/krukkrz/pagination/pkg/config"
+	"github.com/krukkrz/pagination/pkg/generator"
+	"log"
+)
+
+func runSeed(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	cfg := config.Register(fs)
+	books := fs.Int("books", 200, "number of books to insert")
+	cars := fs.Int("cars", 200, "number of cars to insert")
+	seed := fs.Int64("seed", generator.DefaultConfig.Seed, "seed of the generator, the same seed inserts the same rows")
+	ties := fs.Float64("ties", generator.DefaultConfig.Ties, "share of rows created at the same time as the row before them")
+	deleted := fs.Float64("deleted", generator.DefaultConfig.Deleted, "share of ids left without a row, below 1")
+	method := fs.String("method", string(generator.Copy), "how rows are written: copy or insert")
+	batchSize := fs.Int("batch-size", 1000, "rows per statement of the insert method")
+	truncate := fs.Bool("truncate", false, "remove existing books and cars first, otherwise generated ids start after the highest existing ones")
+	fs.Parse(args)
+
+	genConfig := generator.DefaultConfig
+	genConfig.Seed, genConfig.Ties, genConfig.Deleted = *seed, *ties, *deleted
+	if err := genConfig.Validate(); err != nil {
+		return err
+	}
+
+	db, err := connect(cfg, "seed")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	loader := generator.NewLoader(db, generator.Method(*method), *batchSize)
+
+	ctx := context.Background()
+	bookConfig, carConfig := genConfig, genConfig
+	if *truncate {
+		if _, err := db.ExecContext(ctx, "TRUNCATE books, cars;"); err != nil {
+			return err
+		}
+	} else {
+		// rows already in the tables, such as those of 02_fill_tables.sql, keep their ids
+		if bookConfig.AfterId, err = generator.LastId(ctx, db, "books", "book_id"); err != nil {
+			return err
+		}
+		if carConfig.AfterId, err = generator.LastId(ctx, db, "cars", "car_id"); err != nil {
+			return err
+		}
+	}
+	if err := loader.LoadBooks(ctx, bookConfig, *books); err != nil {
+		return err
+	}
+	if err := loader.LoadCars(ctx, carConfig, *cars); err != nil {
+		return err
+	}
+	if err := generator.SyncSequences(ctx, db); err != nil {
+		return err
+	}
+	log.Printf("inserted %d books and %d cars", *books, *cars)
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"expvar"
+	"flag"
+	"fmt"
+	"github.com/krukkrz/pagination/pkg/api"
+	"github.com/krukkrz/pagination/pkg/auth"
+	"github.com/krukkrz/pagination/pkg/cache"
+	"github.com/krukkrz/pagination/pkg/checkpoint"
+	"github.com/krukkrz/pagination/pkg/config"
+	"github.com/krukkrz/pagination/pkg/cors"
+	"github.com/krukkrz/pagination/pkg/ratelimit"
+	"github.com/krukkrz/pagination/pkg/scan"
+	"github.com/krukkrz/pagination/pkg/storage"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	cfg := config.Register(fs)
+	coalescing := fs.Bool("coalesce", true, "share one query between concurrent requests for the same page")
+	cacheTTL := fs.Duration("cache-ttl", 0, "how long pages are cached in memory, 0 disables the cache; with ramsql, writes made in the database stay unseen until pages expire")
+	cacheSize := fs.Int64("cache-size", 64<<20, "maximum size of cached pages in bytes")
+	rateLimit := fs.Float64("rate-limit", 0, "requests per second allowed for every client on every resource, 0 disables rate limiting")
+	rateBurst := fs.Float64("rate-burst", 20, "requests a client may send at once")
+	costLimit := fs.Float64("cost-limit", 50, "cost units per second allowed for every client, deep offsets and large limits cost more")
+	maxLimit := fs.Int("max-limit", 1000, "largest limit a client may ask for")
+	apiKeys := fs.String("api-keys", "", "file with API keys, or \"table\" to read them from the api_keys table; authentication is disabled when empty")
+	corsOrigins := fs.String("cors-origins", "", "comma separated origins allowed to call the API from a browser, \"*\" allows any; CORS is disabled when empty")
+	corsCredentials := fs.Bool("cors-credentials", false, "allow browsers to send credentials with cross-origin requests, only with listed origins")
+	snapshotTTL := fs.Duration("snapshot-ttl", 15*time.Minute, "how long the pages of a pagination started with ?consistent=true, which hides later inserts, can be requested")
+	scanTTL := fs.Duration("scan-ttl", scan.DefaultConfig.TTL, "how long a scan opened on /cars/scans stays open without being fetched")
+	scansPerClient := fs.Int("scans-per-client", scan.DefaultConfig.MaxPerClient, "scans a single client may keep open")
+	scansMax := fs.Int("scans-max", scan.DefaultConfig.MaxOpen, "scans open at once, each of them holds a database connection")
+	checkpointEvery := fs.Int("checkpoint-every", 1000, "rows between the checkpoints serving ?page=N on cars, 0 disables numbered pages")
+	checkpointRefresh := fs.Duration("checkpoint-refresh", time.Minute, "how often the checkpoints of cars are rebuilt")
+	counts := fs.Bool("counts", false, "add the position of the car to pages around it, which counts every car before it")
+	adminAddr := fs.String("admin-addr", "localhost:8001", "address serving /debug/vars, keep it private; empty disables it")
+	gzipLevel := fs.Int("gzip-level", gzip.DefaultCompression, "gzip level of responses, from 1 (fastest) to 9 (smallest), 0 disables compression")
+	fs.Parse(args)
+
+	compression := api.Compression{Level: *gzipLevel, MinSize: 1024}
+	if err := compression.Validate(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Println("Starting application...")
+	store, err := storage.Open(cfg.Storage, cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if *coalescing {
+		store.UseCoalescing()
+	}
+	if *cacheTTL > 0 {
+		pageCache := cache.New(cache.Config{TTL: *cacheTTL, MaxBytes: *cacheSize})
+		store.UseCache(pageCache)
+		expvar.Publish("page_cache", expvar.Func(func() any { return pageCache.Stats() }))
+	}
+
+	opts := []api.Option{
+		api.WithMaxLimit(*maxLimit),
+		api.WithCompression(compression),
+		api.WithExport(store.BookExporter, store.CarExporter),
+		api.WithSnapshots(store.BookSnapshots, *snapshotTTL),
+		api.WithAround(store.AroundCars),
+		api.WithTimeRanges(store.CreatedCars),
+	}
+	if *counts {
+		opts = append(opts, api.WithCounts(store.CountCars))
+	}
+	if store.BookChanges != nil && store.CarChanges != nil {
+		opts = append(opts, api.WithChanges(store.BookChanges, store.CarChanges))
+	}
+	if store.CarScanner != nil {
+		opts = append(opts, api.WithScans(store.CarScanner, scan.Config{TTL: *scanTTL, MaxPerClient: *scansPerClient, MaxOpen: *scansMax}))
+	}
+	if store.CarCheckpoints != nil && *checkpointEvery > 0 {
+		index := checkpoint.New(store.CarCheckpoints, *checkpointEvery)
+		if err := index.Refresh(ctx); err != nil {
+			// numbered pages answer 503 until a refresh succeeds
+			log.Printf("error while building checkpoints: %v", err)
+		}
+		go index.Run(ctx, *checkpointRefresh)
+		opts = append(opts, api.WithCheckpoints(index, store.SeekCars))
+	}
+	if *rateLimit > 0 {
+		opts = append(opts, api.WithRateLimiter(ratelimit.New(ratelimit.Config{
+			Default: ratelimit.Policy{
+				Requests: ratelimit.Limit{Rate: *rateLimit, Burst: *rateBurst},
+				Cost:     ratelimit.Limit{Rate: *costLimit, Burst: *costLimit * 10},
+			},
+		})))
+	}
+
+	if *apiKeys != "" {
+		keyStore, err := loadKeys(store, *apiKeys)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, api.WithAuthentication(keyStore))
+	}
+
+	if *corsOrigins != "" {
+		corsConfig := cors.DefaultConfig(strings.Split(*corsOrigins, ",")...)
+		corsConfig.AllowCredentials = *corsCredentials
+		if err := corsConfig.Validate(); err != nil {
+			return err
+		}
+		opts = append(opts, api.WithCORS(corsConfig))
+	}
+
+	if *adminAddr != "" {
+		go func() {
+			log.Printf("Serving admin endpoints on: %s", *adminAddr)
+			if err := http.ListenAndServe(*adminAddr, api.AdminHandler()); err != nil {
+				log.Printf("error while serving admin endpoints: %v", err)
+			}
+		}()
+	}
+
+	server := api.NewServer(store.Books, store.Cars, opts...)
+	// deferred after store.Close, so scans end before the database does
+	defer server.Close()
+	return server.Start(ctx, cfg.Addr)
+}
+
+func loadKeys(store *storage.Storage, source string) (*auth.Store, error) {
+	if source != "table" {
+		return auth.LoadFile(source)
+	}
+	if store.DB() == nil {
+		return nil, fmt.Errorf("API keys can not be read from a table of the memory storage")
+	}
+	return auth.LoadTable(context.Background(), store.DB())
+}